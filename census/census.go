@@ -0,0 +1,161 @@
+package census
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Kind says how an object behaves on its own.
+type Kind int
+
+const (
+	Unknown    Kind = iota // still changing after MaxPeriod generations, or not isolated
+	StillLife              // period 1
+	Oscillator             // period > 1, stays in place
+	Spaceship              // moves by Dx, Dy every period
+)
+
+func (kind Kind) String() string {
+	switch kind {
+	case StillLife:
+		return "still life"
+	case Oscillator:
+		return "oscillator"
+	case Spaceship:
+		return "spaceship"
+	default:
+		return "unknown"
+	}
+}
+
+// MaxPeriod is the longest period looked for.
+const MaxPeriod = 64
+
+// Object is one isolated pattern on the board.
+type Object struct {
+	Cells  []util.Cell // on the board
+	Code   string      // canonical form, the same for every phase, rotation and reflection
+	Name   string      // eg. "glider", the Code when the pattern isn't known
+	Kind   Kind
+	Period int
+	Dx, Dy int // displacement every period, for spaceships
+}
+
+// Entry is a line of the census table.
+type Entry struct {
+	Name   string
+	Code   string
+	Kind   Kind
+	Period int
+	Count  int
+}
+
+// Census is every object on the board, and how many of each there are.
+type Census struct {
+	Objects []Object
+	Table   []Entry // most common first
+}
+
+// Take separates the alive cells (eg. FinalTurnComplete.Alive) into objects and identifies them.
+// Cells at most 2 apart belong to the same object, the board wraps around.
+func Take(alive []util.Cell, width, height int) *Census {
+	c := &Census{}
+	counts := make(map[string]*Entry)
+	for _, cells := range objects(alive, width, height) {
+		o := identify(cells)
+		c.Objects = append(c.Objects, o)
+		e, ok := counts[o.Code]
+		if !ok {
+			e = &Entry{Name: o.Name, Code: o.Code, Kind: o.Kind, Period: o.Period}
+			counts[o.Code] = e
+		}
+		e.Count++
+	}
+	for _, e := range counts {
+		c.Table = append(c.Table, *e)
+	}
+	sort.Slice(c.Table, func(i, j int) bool {
+		if c.Table[i].Count != c.Table[j].Count {
+			return c.Table[i].Count > c.Table[j].Count
+		}
+		return c.Table[i].Code < c.Table[j].Code
+	})
+	return c
+}
+
+// String is the census table as a report.
+func (c *Census) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-8v%-20v%-12v%-8v%v\n", "Count", "Name", "Kind", "Period", "Code")
+	for _, e := range c.Table {
+		name := e.Name
+		if name == e.Code {
+			name = "-"
+		}
+		fmt.Fprintf(&b, "%-8v%-20v%-12v%-8v%v\n", e.Count, name, e.Kind, e.Period, e.Code)
+	}
+	fmt.Fprintf(&b, "%v objects, %v kinds\n", len(c.Objects), len(c.Table))
+	return b.String()
+}
+
+// objects groups the alive cells, each group is given in unwrapped coordinates,
+// so an object crossing the edge of the board stays in one piece.
+func objects(alive []util.Cell, width, height int) [][]util.Cell {
+	var groups [][]util.Cell
+	for _, cluster := range util.Clusters(alive, width, height, 2) {
+		groups = append(groups, cluster.Cells)
+	}
+	return groups
+}
+
+// identify evolves the object on its own until it repeats, up to MaxPeriod generations.
+func identify(cells []util.Cell) Object {
+	o := Object{Cells: cells, Kind: Unknown}
+	phases := []pattern{newPattern(cells)}
+	start := phases[0]
+	for gen := 1; gen <= MaxPeriod; gen++ {
+		next := phases[len(phases)-1].step()
+		if next.equalShape(start) {
+			o.Period = gen
+			o.Dx, o.Dy = next.minX-start.minX, next.minY-start.minY
+			switch {
+			case o.Dx != 0 || o.Dy != 0:
+				o.Kind = Spaceship
+			case gen == 1:
+				o.Kind = StillLife
+			default:
+				o.Kind = Oscillator
+			}
+			break
+		}
+		phases = append(phases, next)
+	}
+
+	if o.Kind == Unknown {
+		// not periodic, name it by how it is now
+		o.Code = fmt.Sprintf("ov_%v", canonical(phases[:1]))
+	} else {
+		o.Code = code(o, phases[:o.Period])
+	}
+	o.Name = o.Code
+	if name, ok := names[o.Code]; ok {
+		o.Name = name
+	}
+	return o
+}
+
+// code is like the apgcodes: xs<cells> for still lifes, xp<period> for oscillators,
+// xq<period> for spaceships, then the smallest form over every phase and symmetry.
+func code(o Object, phases []pattern) string {
+	switch o.Kind {
+	case StillLife:
+		return fmt.Sprintf("xs%v_%v", len(phases[0].cells), canonical(phases))
+	case Oscillator:
+		return fmt.Sprintf("xp%v_%v", o.Period, canonical(phases))
+	default:
+		return fmt.Sprintf("xq%v_%v", o.Period, canonical(phases))
+	}
+}
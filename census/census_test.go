@@ -0,0 +1,95 @@
+package census
+
+import (
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// place puts a picture ('o' is alive) on a width x height board with its top left at x, y.
+func place(picture string, x, y, width, height int) []util.Cell {
+	var cells []util.Cell
+	for j, line := range strings.Split(picture, "\n") {
+		for i, c := range line {
+			if c == 'o' {
+				cells = append(cells, util.Cell{X: (x + i) % width, Y: (y + j) % height})
+			}
+		}
+	}
+	return cells
+}
+
+// step is a turn of the game on a width x height board that wraps around.
+func step(alive []util.Cell, width, height int) []util.Cell {
+	board := make(map[util.Cell]bool, len(alive))
+	for _, cell := range alive {
+		board[cell] = true
+	}
+	counts := make(map[util.Cell]int)
+	for _, cell := range alive {
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if dx != 0 || dy != 0 {
+					counts[util.Cell{X: (cell.X + dx + width) % width, Y: (cell.Y + dy + height) % height}]++
+				}
+			}
+		}
+	}
+	var next []util.Cell
+	for cell, n := range counts {
+		if n == 3 || n == 2 && board[cell] {
+			next = append(next, cell)
+		}
+	}
+	return next
+}
+
+func TestTakeNames(t *testing.T) {
+	var alive []util.Cell
+	alive = append(alive, place(known["glider"], 2, 2, 32, 32)...)
+	alive = append(alive, place(known["blinker"], 15, 15, 32, 32)...)
+	alive = append(alive, place(known["block"], 25, 25, 32, 32)...)
+	// the same block again, across the corner of the board
+	alive = append(alive, place(known["block"], 31, 31, 32, 32)...)
+	// a glider going the other way, upside down
+	alive = append(alive, place("ooo\n..o\n.o.", 10, 24, 32, 32)...)
+
+	c := Take(alive, 32, 32)
+	if len(c.Objects) != 5 {
+		t.Fatalf("%v objects, want 5\n%v", len(c.Objects), c)
+	}
+	want := map[string]Entry{
+		"glider":  {Kind: Spaceship, Period: 4, Count: 2},
+		"block":   {Kind: StillLife, Period: 1, Count: 2},
+		"blinker": {Kind: Oscillator, Period: 2, Count: 1},
+	}
+	if len(c.Table) != len(want) {
+		t.Fatalf("%v kinds, want %v\n%v", len(c.Table), len(want), c)
+	}
+	for _, e := range c.Table {
+		w, ok := want[e.Name]
+		if !ok {
+			t.Fatalf("unexpected %v\n%v", e.Name, c)
+		}
+		if e.Kind != w.Kind || e.Period != w.Period || e.Count != w.Count {
+			t.Errorf("%v is %v period %v x%v, want %v period %v x%v", e.Name, e.Kind, e.Period, e.Count, w.Kind, w.Period, w.Count)
+		}
+	}
+}
+
+// TestTakeEveryPhase names a glider and a blinker whatever phase they are in.
+func TestTakeEveryPhase(t *testing.T) {
+	glider := place(known["glider"], 5, 5, 20, 20)
+	blinker := place(known["blinker"], 5, 5, 20, 20)
+	for turn := 0; turn < 8; turn++ {
+		if name := Take(glider, 20, 20).Table[0].Name; name != "glider" {
+			t.Errorf("glider at turn %v named %v", turn, name)
+		}
+		if name := Take(blinker, 20, 20).Table[0].Name; name != "blinker" {
+			t.Errorf("blinker at turn %v named %v", turn, name)
+		}
+		glider = step(glider, 20, 20)
+		blinker = step(blinker, 20, 20)
+	}
+}
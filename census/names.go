@@ -0,0 +1,53 @@
+package census
+
+import (
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// known patterns in one phase, 'o' is alive
+var known = map[string]string{
+	"block":            "oo\noo",
+	"beehive":          ".oo.\no..o\n.oo.",
+	"loaf":             ".oo.\no..o\n.o.o\n..o.",
+	"boat":             "oo.\no.o\n.o.",
+	"ship":             "oo.\no.o\n.oo",
+	"tub":              ".o.\no.o\n.o.",
+	"pond":             ".oo.\no..o\no..o\n.oo.",
+	"long boat":        "oo..\no.o.\n.o.o\n..o.",
+	"barge":            ".o..\no.o.\n.o.o\n..o.",
+	"snake":            "oo.o\no.oo",
+	"aircraft carrier": "oo..\no..o\n..oo",
+	"eater 1":          "oo..\no.o.\n..o.\n..oo",
+	"mango":            ".oo.\no..o\n.o..o\n..oo.",
+	"blinker":          "ooo",
+	"toad":             ".ooo\nooo.",
+	"beacon":           "oo..\noo..\n..oo\n..oo",
+	"clock":            "..o.\no.o.\n.o.o\n.o..",
+	"pulsar": "..ooo...ooo..\n.............\no....o.o....o\no....o.o....o\no....o.o....o\n..ooo...ooo..\n" +
+		".............\n..ooo...ooo..\no....o.o....o\no....o.o....o\no....o.o....o\n.............\n..ooo...ooo..",
+	"pentadecathlon": "..o....o..\noo.oooo.oo\n..o....o..",
+	"glider":         ".o.\n..o\nooo",
+	"lwss":           ".o..o\no....\no...o\noooo.",
+	"mwss":           "...o..\n.o...o\no.....\no....o\nooooo.",
+	"hwss":           "...oo..\n.o....o\no......\no.....o\noooooo.",
+}
+
+// names maps the code of each known pattern to its name.
+var names = make(map[string]string)
+
+func init() {
+	for name, picture := range known {
+		var cells []util.Cell
+		for y, line := range strings.Split(picture, "\n") {
+			for x, c := range line {
+				if c == 'o' {
+					cells = append(cells, util.Cell{X: x, Y: y})
+				}
+			}
+		}
+		o := identify(cells)
+		names[o.Code] = name
+	}
+}
@@ -0,0 +1,143 @@
+package census
+
+import (
+	"fmt"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// pattern is a set of cells on an unbounded plane.
+type pattern struct {
+	cells      map[util.Cell]bool
+	minX, minY int
+	maxX, maxY int
+}
+
+func newPattern(cells []util.Cell) pattern {
+	p := pattern{cells: make(map[util.Cell]bool, len(cells))}
+	for i, cell := range cells {
+		p.cells[cell] = true
+		if i == 0 || cell.X < p.minX {
+			p.minX = cell.X
+		}
+		if i == 0 || cell.Y < p.minY {
+			p.minY = cell.Y
+		}
+		if i == 0 || cell.X > p.maxX {
+			p.maxX = cell.X
+		}
+		if i == 0 || cell.Y > p.maxY {
+			p.maxY = cell.Y
+		}
+	}
+	return p
+}
+
+// step is one generation of the Game of Life.
+func (p pattern) step() pattern {
+	counts := make(map[util.Cell]int, len(p.cells)*4)
+	for cell := range p.cells {
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if dx != 0 || dy != 0 {
+					counts[util.Cell{X: cell.X + dx, Y: cell.Y + dy}]++
+				}
+			}
+		}
+	}
+	var next []util.Cell
+	for cell, n := range counts {
+		if n == 3 || n == 2 && p.cells[cell] {
+			next = append(next, cell)
+		}
+	}
+	return newPattern(next)
+}
+
+// equalShape is true if q is p moved.
+func (p pattern) equalShape(q pattern) bool {
+	if len(p.cells) != len(q.cells) || p.maxX-p.minX != q.maxX-q.minX || p.maxY-p.minY != q.maxY-q.minY {
+		return false
+	}
+	for cell := range p.cells {
+		if !q.cells[util.Cell{X: cell.X - p.minX + q.minX, Y: cell.Y - p.minY + q.minY}] {
+			return false
+		}
+	}
+	return true
+}
+
+// form is the pattern as a hex string: the width, then each column of the bounding box
+// as bits from the top, in hex. transform maps (x, y) in the box of size w, h to the new box.
+func (p pattern) form(t int) string {
+	w, h := p.maxX-p.minX+1, p.maxY-p.minY+1
+	if len(p.cells) == 0 {
+		return "0"
+	}
+	tw, th := w, h
+	if t >= 4 {
+		tw, th = h, w
+	}
+	grid := make([][]bool, tw)
+	for i := range grid {
+		grid[i] = make([]bool, th)
+	}
+	for cell := range p.cells {
+		x, y := cell.X-p.minX, cell.Y-p.minY
+		tx, ty := transform(t, x, y, w, h)
+		grid[tx][ty] = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%vx%v", tw, th)
+	for _, column := range grid {
+		b.WriteByte('_')
+		for y := 0; y < th; y += 4 {
+			var nibble int
+			for bit := 0; bit < 4 && y+bit < th; bit++ {
+				if column[y+bit] {
+					nibble |= 1 << uint(bit)
+				}
+			}
+			b.WriteString(fmt.Sprintf("%x", nibble))
+		}
+	}
+	return b.String()
+}
+
+// transform is one of the 8 symmetries of a w x h box.
+func transform(t, x, y, w, h int) (int, int) {
+	switch t {
+	case 0:
+		return x, y
+	case 1:
+		return w - 1 - x, y
+	case 2:
+		return x, h - 1 - y
+	case 3:
+		return w - 1 - x, h - 1 - y
+	case 4:
+		return y, x
+	case 5:
+		return h - 1 - y, x
+	case 6:
+		return y, w - 1 - x
+	default:
+		return h - 1 - y, w - 1 - x
+	}
+}
+
+// canonical is the smallest form of any phase under any symmetry.
+func canonical(phases []pattern) string {
+	best := ""
+	for _, p := range phases {
+		for t := 0; t < 8; t++ {
+			f := p.form(t)
+			if best == "" || len(f) < len(best) || len(f) == len(best) && f < best {
+				best = f
+			}
+		}
+	}
+	return best
+}
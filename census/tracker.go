@@ -0,0 +1,354 @@
+package census
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Fate is how a track ended.
+type Fate int
+
+const (
+	Alive    Fate = iota // still being followed
+	Died                 // no cells left
+	Collided             // merged with other objects, the largest one keeps its ID
+	Split                // broke into pieces, the largest one keeps its ID
+	Left                 // crossed the edge of the board, when not wrapping
+)
+
+func (fate Fate) String() string {
+	switch fate {
+	case Alive:
+		return "alive"
+	case Died:
+		return "died"
+	case Collided:
+		return "collided"
+	case Split:
+		return "split"
+	default:
+		return "left"
+	}
+}
+
+// Position is where a track was after a turn, the centre of its cells.
+// X and Y don't wrap, they keep growing as the object goes round the board.
+type Position struct {
+	Turn  int
+	X, Y  float64
+	Cells int
+}
+
+// Track is one object followed from generation to generation.
+// Kind, Name, Period, Dx and Dy are known once its shape repeats.
+type Track struct {
+	ID         int
+	Start, End int // End is the last turn the track was seen
+	Fate       Fate
+	Kind       Kind
+	Name       string
+	Period     int
+	Dx, Dy     int
+	Path       []Position
+
+	phases []pattern // the last shapes, newest last, to find the period
+}
+
+// Velocity is the displacement per turn of a spaceship, in cells.
+func (t *Track) Velocity() (vx, vy float64) {
+	if t.Kind != Spaceship {
+		return 0, 0
+	}
+	return float64(t.Dx) / float64(t.Period), float64(t.Dy) / float64(t.Period)
+}
+
+// TrackEvent reports a track ending, or a track starting from others.
+// With is the other tracks in a collision, Into the tracks the pieces of a split
+// (or an object that left the board) carry on as.
+type TrackEvent struct {
+	Turn int
+	ID   int
+	Fate Fate
+	With []int
+	Into []int
+}
+
+func (e TrackEvent) String() string {
+	s := fmt.Sprintf("turn %v: object %v %v", e.Turn, e.ID, e.Fate)
+	if len(e.With) > 0 {
+		s += fmt.Sprintf(" with %v", e.With)
+	}
+	if len(e.Into) > 0 {
+		s += fmt.Sprintf(" into %v", e.Into)
+	}
+	return s
+}
+
+// Tracker follows the objects of the board. Every cell of the next generation is within
+// 1 of a cell of the last one, so objects that touch across a generation are the same object.
+type Tracker struct {
+	Wrap bool // follow objects round the board instead of ending them as Left
+
+	width, height int
+	nextID        int
+	tracks        []*Track          // every track, by ID
+	last          map[util.Cell]int // board cell -> ID of the track it was part of
+}
+
+// NewTracker makes a tracker for a width x height board.
+func NewTracker(width, height int) *Tracker {
+	return &Tracker{width: width, height: height}
+}
+
+// Tracks is every track so far, in order of ID.
+func (tr *Tracker) Tracks() []*Track {
+	return tr.tracks
+}
+
+// Update gives the tracker the alive cells after turn and returns the tracks that ended.
+func (tr *Tracker) Update(turn int, alive []util.Cell) []TrackEvent {
+	groups := objects(alive, tr.width, tr.height)
+
+	// the tracks each group grew from
+	parents := make([][]int, len(groups))
+	children := make(map[int][]int)
+	for i, group := range groups {
+		seen := make(map[int]bool)
+		for _, cell := range group {
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					id, ok := tr.last[tr.wrap(cell.X+dx, cell.Y+dy)]
+					if ok && !seen[id] {
+						seen[id] = true
+						parents[i] = append(parents[i], id)
+						children[id] = append(children[id], i)
+					}
+				}
+			}
+		}
+		sort.Ints(parents[i])
+	}
+
+	var events []TrackEvent
+	owner := make([]*Track, len(groups))
+
+	// a track carries on as its largest child, unless that child has a larger parent
+	for id, kids := range children {
+		largest := kids[0]
+		for _, k := range kids[1:] {
+			if len(groups[k]) > len(groups[largest]) {
+				largest = k
+			}
+		}
+		if tr.heir(parents[largest]) == id {
+			owner[largest] = tr.tracks[id]
+		}
+	}
+	for i, group := range groups {
+		if owner[i] == nil {
+			owner[i] = tr.start(turn)
+		}
+		tr.move(owner[i], turn, group, len(parents[i]) != 1 || len(children[parents[i][0]]) != 1)
+	}
+
+	// the tracks that ended, or lost or gained cells to other objects
+	for id, track := range tr.tracks {
+		if track.Fate != Alive || track.Start == turn {
+			continue
+		}
+		carriesOn := track.End == turn
+		kids := children[id]
+		switch {
+		case len(kids) == 0:
+			track.Fate = Died
+			events = append(events, TrackEvent{Turn: turn, ID: id, Fate: Died})
+		case len(kids) > 1:
+			e := TrackEvent{Turn: turn, ID: id, Fate: Split}
+			for _, k := range kids {
+				if owner[k] != track {
+					e.Into = append(e.Into, owner[k].ID)
+				}
+			}
+			if !carriesOn {
+				track.Fate = Split
+			}
+			events = append(events, e)
+		case len(parents[kids[0]]) > 1:
+			e := TrackEvent{Turn: turn, ID: id, Fate: Collided}
+			for _, other := range parents[kids[0]] {
+				if other != id {
+					e.With = append(e.With, other)
+				}
+			}
+			if !carriesOn {
+				track.Fate = Collided
+				e.Into = []int{owner[kids[0]].ID}
+			}
+			events = append(events, e)
+		}
+	}
+
+	// objects that crossed the edge carry on as new tracks
+	if !tr.Wrap {
+		for i, track := range owner {
+			if track.Start == turn || !tr.crossed(track) {
+				continue
+			}
+			track.Fate = Left
+			track.Path = track.Path[:len(track.Path)-1]
+			track.End = turn - 1
+			owner[i] = tr.start(turn)
+			tr.move(owner[i], turn, groups[i], true)
+			events = append(events, TrackEvent{Turn: turn, ID: track.ID, Fate: Left, Into: []int{owner[i].ID}})
+		}
+	}
+
+	tr.last = make(map[util.Cell]int, len(alive))
+	for i, group := range groups {
+		for _, cell := range group {
+			tr.last[tr.wrap(cell.X, cell.Y)] = owner[i].ID
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events
+}
+
+// heir is the parent that keeps its ID when parents merge: the one with the most cells.
+func (tr *Tracker) heir(parents []int) int {
+	best := parents[0]
+	for _, id := range parents[1:] {
+		if tr.cells(id) > tr.cells(best) {
+			best = id
+		}
+	}
+	return best
+}
+
+func (tr *Tracker) cells(id int) int {
+	path := tr.tracks[id].Path
+	return path[len(path)-1].Cells
+}
+
+func (tr *Tracker) start(turn int) *Track {
+	track := &Track{ID: tr.nextID, Start: turn, Kind: Unknown}
+	tr.nextID++
+	tr.tracks = append(tr.tracks, track)
+	return track
+}
+
+// move adds the cells of the track after turn. A changed track forgets its old shapes.
+func (tr *Tracker) move(track *Track, turn int, cells []util.Cell, changed bool) {
+	var x, y float64
+	for _, cell := range cells {
+		x += float64(cell.X)
+		y += float64(cell.Y)
+	}
+	x /= float64(len(cells))
+	y /= float64(len(cells))
+	if len(track.Path) == 0 {
+		x = math.Mod(x+float64(tr.width), float64(tr.width))
+		y = math.Mod(y+float64(tr.height), float64(tr.height))
+	} else {
+		// the copy of the object nearest where it was
+		last := track.Path[len(track.Path)-1]
+		x += float64(tr.width) * math.Round((last.X-x)/float64(tr.width))
+		y += float64(tr.height) * math.Round((last.Y-y)/float64(tr.height))
+	}
+	track.Path = append(track.Path, Position{Turn: turn, X: x, Y: y, Cells: len(cells)})
+	track.End = turn
+
+	if changed {
+		track.phases = nil
+		track.Kind, track.Name, track.Period, track.Dx, track.Dy = Unknown, "", 0, 0, 0
+	}
+	p := newPattern(cells)
+	if track.Period == 0 {
+		for k := 1; k <= len(track.phases); k++ {
+			old := track.phases[len(track.phases)-k]
+			if p.equalShape(old) {
+				o := Object{Period: k}
+				o.Dx, o.Dy = tr.displacement(track, k)
+				switch {
+				case o.Dx != 0 || o.Dy != 0:
+					o.Kind = Spaceship
+				case k == 1:
+					o.Kind = StillLife
+				default:
+					o.Kind = Oscillator
+				}
+				track.Kind, track.Period, track.Dx, track.Dy = o.Kind, k, o.Dx, o.Dy
+				phases := append([]pattern{p}, track.phases[len(track.phases)-k+1:]...)
+				track.Name = code(o, phases)
+				if name, ok := names[track.Name]; ok {
+					track.Name = name
+				}
+				break
+			}
+		}
+	}
+	track.phases = append(track.phases, p)
+	if len(track.phases) > MaxPeriod {
+		track.phases = track.phases[1:]
+	}
+}
+
+// displacement is how far the track moved in the last k turns, the shapes are the same so
+// their centres are a whole number of cells apart.
+func (tr *Tracker) displacement(track *Track, k int) (int, int) {
+	now, then := track.Path[len(track.Path)-1], track.Path[len(track.Path)-1-k]
+	return int(math.Round(now.X - then.X)), int(math.Round(now.Y - then.Y))
+}
+
+// crossed is true if the last move of the track took its centre over the edge of the board.
+func (tr *Tracker) crossed(track *Track) bool {
+	if len(track.Path) < 2 {
+		return false
+	}
+	now, then := track.Path[len(track.Path)-1], track.Path[len(track.Path)-2]
+	w, h := float64(tr.width), float64(tr.height)
+	return math.Floor(now.X/w) != math.Floor(then.X/w) || math.Floor(now.Y/h) != math.Floor(then.Y/h)
+}
+
+func (tr *Tracker) wrap(x, y int) util.Cell {
+	return util.Cell{X: (x%tr.width + tr.width) % tr.width, Y: (y%tr.height + tr.height) % tr.height}
+}
+
+// WriteLog writes the trajectory of every track as csv: id,turn,x,y,cells.
+func (tr *Tracker) WriteLog(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "id,turn,x,y,cells"); err != nil {
+		return err
+	}
+	for _, track := range tr.tracks {
+		for _, pos := range track.Path {
+			_, err := fmt.Fprintf(w, "%v,%v,%.2f,%.2f,%v\n", track.ID, pos.Turn, pos.X, pos.Y, pos.Cells)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// String is a line per track that found its period or ended before the last turn.
+func (tr *Tracker) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-6v%-8v%-8v%-10v%-20v%-8v%v\n", "ID", "Start", "End", "Fate", "Name", "Period", "Velocity")
+	for _, track := range tr.tracks {
+		if track.Period == 0 && track.Fate == Alive {
+			continue
+		}
+		name := track.Name
+		if name == "" {
+			name = "-"
+		}
+		vx, vy := track.Velocity()
+		fmt.Fprintf(&b, "%-6v%-8v%-8v%-10v%-20v%-8v(%.2f, %.2f)\n",
+			track.ID, track.Start, track.End, track.Fate, name, track.Period, vx, vy)
+	}
+	return b.String()
+}
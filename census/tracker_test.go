@@ -0,0 +1,66 @@
+package census
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTrackerGliderWrap follows a glider round a 16x16 board and over the edge.
+func TestTrackerGliderWrap(t *testing.T) {
+	tr := NewTracker(16, 16)
+	tr.Wrap = true
+	alive := place(known["glider"], 6, 6, 16, 16)
+	for turn := 0; turn <= 80; turn++ {
+		if events := tr.Update(turn, alive); len(events) != 0 {
+			t.Fatalf("turn %v: %v", turn, events)
+		}
+		alive = step(alive, 16, 16)
+	}
+
+	if len(tr.Tracks()) != 1 {
+		t.Fatalf("%v tracks, want 1\n%v", len(tr.Tracks()), tr)
+	}
+	track := tr.Tracks()[0]
+	if track.Fate != Alive || track.Name != "glider" || track.Kind != Spaceship || track.Period != 4 {
+		t.Fatalf("track is %v %v %v period %v", track.Fate, track.Name, track.Kind, track.Period)
+	}
+	if track.Dx != 1 || track.Dy != 1 {
+		t.Fatalf("glider moves %v, %v every period, want 1, 1", track.Dx, track.Dy)
+	}
+	// 80 turns is 20 cells, past the edge of the board
+	first, last := track.Path[0], track.Path[len(track.Path)-1]
+	if math.Abs(last.X-first.X-20) > 1e-9 || math.Abs(last.Y-first.Y-20) > 1e-9 {
+		t.Fatalf("glider went from %v, %v to %v, %v, want 20 cells down and right", first.X, first.Y, last.X, last.Y)
+	}
+}
+
+// TestTrackerGliderLeaves ends the glider's track at the edge when the tracker doesn't wrap.
+func TestTrackerGliderLeaves(t *testing.T) {
+	tr := NewTracker(16, 16)
+	alive := place(known["glider"], 6, 6, 16, 16)
+	var left []TrackEvent
+	for turn := 0; turn <= 80; turn++ {
+		for _, e := range tr.Update(turn, alive) {
+			if e.Fate != Left {
+				t.Fatalf("turn %v: %v", turn, e)
+			}
+			left = append(left, e)
+		}
+		alive = step(alive, 16, 16)
+	}
+	if len(left) == 0 {
+		t.Fatal("the glider never left the board")
+	}
+	for _, e := range left {
+		if len(e.Into) != 1 {
+			t.Fatalf("%v doesn't carry on as one track", e)
+		}
+		if next := tr.Tracks()[e.Into[0]]; next.Start != e.Turn {
+			t.Fatalf("%v carries on as track %v from turn %v", e, next.ID, next.Start)
+		}
+	}
+	last := tr.Tracks()[len(tr.Tracks())-1]
+	if last.Fate != Alive || last.Name != "glider" {
+		t.Fatalf("the last track is %v %v", last.Fate, last.Name)
+	}
+}
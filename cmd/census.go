@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"uk.ac.bris.cs/gameoflife/census"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// RunCensus runs the Game of Life without SDL and reports the objects left at the end.
+func RunCensus(params gol.Params) {
+	events := make(chan gol.Event, 1000)
+	gol.Run(params, events, nil, nil)
+
+	for event := range events {
+		switch e := event.(type) {
+		case gol.StabilityReached:
+			fmt.Println(e)
+		case gol.FinalTurnComplete:
+			fmt.Println("Census after turn", e.CompletedTurns)
+			fmt.Print(census.Take(e.Alive, params.ImageWidth, params.ImageHeight))
+		}
+	}
+}
+
+func main() {
+	var params gol.Params
+
+	flag.IntVar(
+		&params.Threads,
+		"t",
+		8,
+		"Specify the number of worker threads to use. Defaults to 8.")
+
+	flag.IntVar(
+		&params.ImageWidth,
+		"w",
+		512,
+		"Specify the width of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&params.ImageHeight,
+		"h",
+		512,
+		"Specify the height of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&params.Turns,
+		"turns",
+		100,
+		"Specify the number of turns to process. Defaults to 100.")
+
+	flag.BoolVar(
+		&params.StopWhenStable,
+		"stopStable",
+		false,
+		"Specify stopping once the world is static or periodic. Defaults to false.")
+
+	var image string
+	flag.StringVar(
+		&image,
+		"i",
+		"",
+		"Specify a pgm image to take the census of instead of running. Defaults to none.")
+
+	flag.Parse()
+
+	if image != "" {
+		alive := util.ReadAliveCells(image, params.ImageWidth, params.ImageHeight)
+		fmt.Print(census.Take(alive, params.ImageWidth, params.ImageHeight))
+		return
+	}
+
+	fmt.Println("Threads:", params.Threads)
+	fmt.Println("Width:", params.ImageWidth)
+	fmt.Println("Height:", params.ImageHeight)
+	RunCensus(params)
+}
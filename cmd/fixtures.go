@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// rule is a life-like rule, born[n] and survive[n] for n alive neighbours.
+type rule struct {
+	born, survive [9]bool
+}
+
+// parseRule reads a rule like B3/S23, the Game of Life. The parts can be in either order.
+func parseRule(s string) (rule, error) {
+	var r rule
+	parts := strings.Split(strings.ToUpper(s), "/")
+	if len(parts) != 2 {
+		return r, errors.New("rule should look like B3/S23")
+	}
+	for _, part := range parts {
+		var counts *[9]bool
+		switch {
+		case strings.HasPrefix(part, "B"):
+			counts = &r.born
+		case strings.HasPrefix(part, "S"):
+			counts = &r.survive
+		default:
+			return r, errors.New("rule should look like B3/S23")
+		}
+		for _, c := range part[1:] {
+			if c < '0' || c > '8' {
+				return r, fmt.Errorf("%q isn't a neighbour count", c)
+			}
+			counts[c-'0'] = true
+		}
+	}
+	return r, nil
+}
+
+// step is the reference implementation: every cell counts its 8 neighbours on the torus
+// in the old world and is written to a new world. Slow, but nothing is shared or skipped.
+func step(world [][]bool, r rule) [][]bool {
+	width, height := len(world), len(world[0])
+	next := make([][]bool, width)
+	for x := range next {
+		next[x] = make([]bool, height)
+		for y := range next[x] {
+			n := 0
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					if (dx != 0 || dy != 0) && world[(x+dx+width)%width][(y+dy+height)%height] {
+						n++
+					}
+				}
+			}
+			if world[x][y] {
+				next[x][y] = r.survive[n]
+			} else {
+				next[x][y] = r.born[n]
+			}
+		}
+	}
+	return next
+}
+
+func countAlive(world [][]bool) int {
+	n := 0
+	for _, column := range world {
+		for _, v := range column {
+			if v {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// writePgm writes the world like check/images, alive is 255.
+func writePgm(filename string, world [][]bool) {
+	file, err := os.Create(filename)
+	util.Check(err)
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	width, height := len(world), len(world[0])
+	_, _ = fmt.Fprintf(w, "P5\n%v %v\n%v\n", width, height, 255)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var v byte
+			if world[x][y] {
+				v = 255
+			}
+			util.Check(w.WriteByte(v))
+		}
+	}
+	util.Check(w.Flush())
+	fmt.Println("File", filename, "output done!")
+}
+
+// RunFixtures runs the reference implementation from the image and writes
+// dir/images/WxHxT.pgm for each turn and dir/alive/WxH.csv for the first aliveTurns turns.
+func RunFixtures(input string, width, height int, turns []int, aliveTurns int, r rule, dir string) {
+	world := make([][]bool, width)
+	for x := range world {
+		world[x] = make([]bool, height)
+	}
+	for _, cell := range util.ReadAliveCells(input, width, height) {
+		world[cell.X][cell.Y] = true
+	}
+
+	util.Check(os.MkdirAll(filepath.Join(dir, "images"), os.ModePerm))
+	var csv *bufio.Writer
+	if aliveTurns > 0 {
+		util.Check(os.MkdirAll(filepath.Join(dir, "alive"), os.ModePerm))
+		filename := filepath.Join(dir, "alive", fmt.Sprintf("%vx%v.csv", width, height))
+		file, err := os.Create(filename)
+		util.Check(err)
+		defer file.Close()
+		csv = bufio.NewWriter(file)
+		_, _ = fmt.Fprintln(csv, "completed_turns,alive_cells")
+		defer func() {
+			util.Check(csv.Flush())
+			fmt.Println("File", filename, "output done!")
+		}()
+	}
+
+	last := aliveTurns
+	wanted := make(map[int]bool)
+	for _, t := range turns {
+		wanted[t] = true
+		if t > last {
+			last = t
+		}
+	}
+	for turn := 0; turn <= last; turn++ {
+		if turn > 0 {
+			world = step(world, r)
+		}
+		if wanted[turn] {
+			writePgm(filepath.Join(dir, "images", fmt.Sprintf("%vx%vx%v.pgm", width, height, turn)), world)
+		}
+		if turn > 0 && turn <= aliveTurns {
+			_, _ = fmt.Fprintf(csv, "%v,%v\n", turn, countAlive(world))
+		}
+	}
+}
+
+func main() {
+	var width, height, aliveTurns int
+	var input, turnList, ruleString, dir string
+
+	flag.IntVar(
+		&width,
+		"w",
+		512,
+		"Specify the width of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&height,
+		"h",
+		512,
+		"Specify the height of the image. Defaults to 512.")
+
+	flag.StringVar(
+		&input,
+		"i",
+		"",
+		"Specify the starting image. Defaults to images/WxH.pgm.")
+
+	flag.StringVar(
+		&turnList,
+		"turns",
+		"0,1,100",
+		"Specify the turns to write an image of, separated by commas. Defaults to 0,1,100.")
+
+	flag.IntVar(
+		&aliveTurns,
+		"alive",
+		10000,
+		"Specify the number of turns in the alive cells csv, 0 writes none. Defaults to 10000.")
+
+	flag.StringVar(
+		&ruleString,
+		"rule",
+		"B3/S23",
+		"Specify the rule as births/survivals. Defaults to B3/S23, the Game of Life.")
+
+	flag.StringVar(
+		&dir,
+		"o",
+		"check",
+		"Specify the directory for images/ and alive/. Defaults to check.")
+
+	flag.Parse()
+
+	if input == "" {
+		input = fmt.Sprintf("images/%vx%v.pgm", width, height)
+	}
+	var turns []int
+	for _, s := range strings.Split(turnList, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		t, err := strconv.Atoi(s)
+		util.Check(err)
+		turns = append(turns, t)
+	}
+	r, err := parseRule(ruleString)
+	util.Check(err)
+
+	fmt.Println("Width:", width)
+	fmt.Println("Height:", height)
+	fmt.Println("Rule:", ruleString)
+	RunFixtures(input, width, height, turns, aliveTurns, r, dir)
+}
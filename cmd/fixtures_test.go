@@ -0,0 +1,94 @@
+package main
+
+// Run with go test cmd/fixtures.go cmd/fixtures_test.go, every file in cmd/ is its own program.
+
+import (
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+func TestParseRule(t *testing.T) {
+	life, err := parseRule("B3/S23")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n <= 8; n++ {
+		if life.born[n] != (n == 3) || life.survive[n] != (n == 2 || n == 3) {
+			t.Fatalf("B3/S23 has born %v survive %v", life.born, life.survive)
+		}
+	}
+	if swapped, err := parseRule("s23/b3"); err != nil || swapped != life {
+		t.Errorf("s23/b3 is %+v, %v", swapped, err)
+	}
+	for _, s := range []string{"", "B3", "B3/S23/S4", "B9/S23", "B3/X23", "3/23"} {
+		if _, err := parseRule(s); err == nil {
+			t.Errorf("%q parsed without an error", s)
+		}
+	}
+}
+
+// TestStepBlinker checks a blinker turns vertical and back.
+func TestStepBlinker(t *testing.T) {
+	life, _ := parseRule("B3/S23")
+	world := make([][]bool, 5)
+	for x := range world {
+		world[x] = make([]bool, 5)
+	}
+	world[1][2], world[2][2], world[3][2] = true, true, true
+
+	vertical := step(world, life)
+	if countAlive(vertical) != 3 || !vertical[2][1] || !vertical[2][2] || !vertical[2][3] {
+		t.Fatalf("blinker after a turn %v", vertical)
+	}
+	back := step(vertical, life)
+	for x := range world {
+		for y := range world[x] {
+			if back[x][y] != world[x][y] {
+				t.Fatalf("blinker after two turns %v", back)
+			}
+		}
+	}
+}
+
+// TestStepEngine runs the reference step and the engine from images/16x16.pgm and compares them.
+func TestStepEngine(t *testing.T) {
+	util.Check(os.Chdir(".."))
+	defer os.Chdir("cmd")
+
+	life, _ := parseRule("B3/S23")
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Threads: 4}
+	world := make([][]bool, p.ImageWidth)
+	for x := range world {
+		world[x] = make([]bool, p.ImageHeight)
+	}
+	for _, cell := range util.ReadAliveCells("images/16x16.pgm", p.ImageWidth, p.ImageHeight) {
+		world[cell.X][cell.Y] = true
+	}
+
+	for turn := 1; turn <= 100; turn++ {
+		world = step(world, life)
+		if turn != 1 && turn != 10 && turn != 100 {
+			continue
+		}
+		p.Turns = turn
+		events := make(chan gol.Event, 1000)
+		gol.Run(p, events, nil, nil)
+		var alive []util.Cell
+		for event := range events {
+			if e, ok := event.(gol.FinalTurnComplete); ok {
+				alive = e.Alive
+			}
+		}
+		if len(alive) != countAlive(world) {
+			t.Fatalf("turn %v: engine has %v alive, reference %v", turn, len(alive), countAlive(world))
+		}
+		for _, cell := range alive {
+			if !world[cell.X][cell.Y] {
+				t.Fatalf("turn %v: cell (%v,%v) alive in the engine only", turn, cell.X, cell.Y)
+			}
+		}
+	}
+}
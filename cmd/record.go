@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/record"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// RunRecord runs the Game of Life without SDL and writes every frame to a y4m video.
+func RunRecord(params gol.Params, filename string, opts record.Y4MOptions) {
+	file, err := os.Create(filename)
+	util.Check(err)
+	defer file.Close()
+
+	events := make(chan gol.Event, 1000)
+	gol.Run(params, events, nil, nil)
+
+	video := record.NewY4M(file, params, opts)
+	err = video.Record(events)
+	util.Check(err)
+	fmt.Println("Video", filename, "output done!")
+}
+
+func main() {
+	var params gol.Params
+
+	flag.IntVar(
+		&params.Threads,
+		"t",
+		8,
+		"Specify the number of worker threads to use. Defaults to 8.")
+
+	flag.IntVar(
+		&params.ImageWidth,
+		"w",
+		512,
+		"Specify the width of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&params.ImageHeight,
+		"h",
+		512,
+		"Specify the height of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&params.Turns,
+		"turns",
+		100,
+		"Specify the number of turns to process. Defaults to 100.")
+
+	var filename string
+	var opts record.Y4MOptions
+	flag.StringVar(
+		&filename,
+		"o",
+		"out/gol.y4m",
+		"Specify the video file. Defaults to out/gol.y4m.")
+
+	flag.IntVar(
+		&opts.Stride,
+		"stride",
+		1,
+		"Specify a frame every n turns. Defaults to 1.")
+
+	flag.IntVar(
+		&opts.Scale,
+		"scale",
+		1,
+		"Specify the pixels per cell. Defaults to 1.")
+
+	flag.IntVar(
+		&opts.Fps,
+		"fps",
+		30,
+		"Specify the frames per second. Defaults to 30.")
+
+	flag.BoolVar(
+		&opts.Age,
+		"age",
+		false,
+		"Specify colouring cells by age. Defaults to false.")
+
+	flag.Parse()
+
+	fmt.Println("Threads:", params.Threads)
+	fmt.Println("Width:", params.ImageWidth)
+	fmt.Println("Height:", params.ImageHeight)
+	fmt.Println("Video:", filename)
+
+	_ = os.Mkdir("out", os.ModePerm)
+	RunRecord(params, filename, opts)
+}
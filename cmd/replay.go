@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/record"
+	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// RunReplay shows a recorded journal in SDL, nothing is recomputed.
+func RunReplay(filename string, opts record.ReplayOptions) {
+	file, err := os.Open(filename)
+	util.Check(err)
+	defer file.Close()
+
+	reader, err := record.NewJournalReader(file)
+	util.Check(err)
+	params := reader.Params()
+	fmt.Println("Width:", params.ImageWidth)
+	fmt.Println("Height:", params.ImageHeight)
+
+	keyPresses := make(chan rune, 10)
+	events := make(chan gol.Event, 1000)
+	go func() {
+		err := record.Replay(file, events, keyPresses, opts)
+		util.Check(err)
+	}()
+	sdl.Start(params, events, keyPresses)
+}
+
+func main() {
+	runtime.LockOSThread()
+	var filename string
+	var opts record.ReplayOptions
+
+	flag.StringVar(
+		&filename,
+		"j",
+		"out/gol.journal",
+		"Specify the journal file. Defaults to out/gol.journal.")
+
+	flag.Float64Var(
+		&opts.Speed,
+		"speed",
+		30,
+		"Specify the turns per second, 0 is as fast as possible. Defaults to 30.")
+
+	flag.IntVar(
+		&opts.From,
+		"from",
+		0,
+		"Specify the first turn to show. Defaults to 0.")
+
+	flag.IntVar(
+		&opts.Step,
+		"step",
+		100,
+		"Specify the turns skipped by the left and right keys. Defaults to 100.")
+
+	flag.Parse()
+
+	fmt.Println("Journal:", filename)
+	fmt.Println("Keys: p pause, q quit, +/- speed, left/right seek")
+
+	RunReplay(filename, opts)
+}
@@ -8,14 +8,17 @@ import (
 	"net/http"
 	"net/rpc"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 	"time"
 
 	"uk.ac.bris.cs/gameoflife/cs"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
-func RunMasterServer(params gol.Params, ip string, port int) {
+func RunMasterServer(params gol.Params, ip string, port int, slaveIp string, slavePort int) {
 	keyPresses := make(chan rune, 10)
 	events := make(chan gol.Event, 1000)
 
@@ -31,7 +34,7 @@ func RunMasterServer(params gol.Params, ip string, port int) {
 		// key Server
 		var server = &cs.GolServer{OnKeyPress: OnKeyPress}
 		// master Sever
-		var masterAPI = gol.NewGolMasterServer(params, 2, nil)
+		var masterAPI = gol.NewGolMasterServer(params, params.SlaveCount, nil)
 
 		rpc.Register(server)
 		rpc.Register(masterAPI)
@@ -46,8 +49,36 @@ func RunMasterServer(params gol.Params, ip string, port int) {
 		hc.Server = masterAPI
 
 	} else {
-		hc.Client = gol.NewGolSlaveClient(ip, port)
+		// the board is the master's, it may come from a checkpoint
+		client := gol.NewGolSlaveClient(ip, port)
+		master := client.FetchParams()
+		params.ImageWidth, params.ImageHeight, params.Turns = master.ImageWidth, master.ImageHeight, master.Turns
 
+		// the other slaves push their edges here
+		var slaveAPI = gol.NewGolSlaveServer(params)
+		server := rpc.NewServer()
+		server.Register(slaveAPI)
+		l, e := net.Listen("tcp", fmt.Sprintf(":%d", slavePort))
+		if e != nil {
+			log.Fatalln("listen error:", e)
+		}
+		go server.Accept(l)
+
+		hc.Client = client
+		hc.Slave = slaveAPI
+		hc.Address = fmt.Sprintf("%s:%d", slaveIp, slavePort)
+
+		// ctrl-c hands the strip to the other slaves before exiting, a second one exits now
+		interrupt := make(chan os.Signal, 2)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-interrupt
+			fmt.Println("leaving, interrupt again to exit now")
+			go hc.Client.Leave(hc.Address)
+			<-interrupt
+			fmt.Println("exit")
+			os.Exit(1)
+		}()
 	}
 
 	// drop events
@@ -97,7 +128,7 @@ func main() {
 		&params.SlaveCount,
 		"s",
 		2,
-		"Specify the salve count. Defaults to 2.")
+		"Specify the number of slaves to start with, more can join and leave at any turn. Defaults to 2.")
 
 	flag.IntVar(
 		&params.Turns,
@@ -105,6 +136,90 @@ func main() {
 		10000000000,
 		"Specify the number of turns to process. Defaults to 10000000000.")
 
+	flag.IntVar(
+		&params.ChecksumTurns,
+		"checksum",
+		100,
+		"Specify how often in turns the slaves send a checksum of their strip to check the master's world. Defaults to 100.")
+
+	flag.IntVar(
+		&params.TileRows,
+		"tileRows",
+		1,
+		"Specify how many rows of tiles the world is split into, the slaves are shared between them. Defaults to 1 (strips).")
+
+	flag.IntVar(
+		&params.HaloDepth,
+		"halo",
+		1,
+		"Specify how many cells deep the slaves swap edges, they compute that many turns before swapping again. Defaults to 1 (every turn).")
+
+	flag.IntVar(
+		&params.RebalanceTurns,
+		"rebalance",
+		100,
+		"Specify how often in turns the strips are resized when a slave is slower than the others. Defaults to 100.")
+
+	flag.BoolVar(
+		&params.CompressStrips,
+		"rle",
+		false,
+		"Specify whether to run length encode the strips sent between the master and the slaves. Defaults to false.")
+
+	flag.IntVar(
+		&params.AutosaveTurns,
+		"autosave",
+		0,
+		"Specify autosave every n turns. Defaults to 0 (off).")
+
+	flag.DurationVar(
+		&params.AutosaveInterval,
+		"autosaveInterval",
+		0,
+		"Specify autosave every interval, eg. 10m. Defaults to 0 (off).")
+
+	flag.IntVar(
+		&params.AutosaveKeep,
+		"keep",
+		5,
+		"Specify the number of autosaves to keep. Defaults to 5.")
+
+	flag.BoolVar(
+		&params.AutosaveCheckpoint,
+		"autosaveCheckpoint",
+		false,
+		"Specify autosaving checkpoints to resume from instead of pgm images. Defaults to false.")
+
+	flag.StringVar(
+		&params.Resume,
+		"resume",
+		"",
+		"Specify a checkpoint file for the master to continue from, the slaves get the world from the master. Defaults to none.")
+
+	flag.StringVar(
+		&params.OutputTemplate,
+		"out",
+		"",
+		"Specify the output filename template, {width} {height} {turn} are replaced and a .gz suffix compresses. Defaults to {width}x{height}x{turn}.")
+
+	flag.StringVar(
+		&params.SeedImage,
+		"seed",
+		"",
+		"Specify a greyscale image (pgm, png, jpeg, gif) of any size or an rle pattern to start from. Defaults to images/WxH.pgm.")
+
+	flag.StringVar(
+		&params.SeedMode,
+		"seedMode",
+		gol.SeedThreshold,
+		"Specify how the seed becomes cells: threshold, otsu or dither. Defaults to threshold.")
+
+	flag.IntVar(
+		&params.SeedThreshold,
+		"threshold",
+		128,
+		"Specify the grey level from which a seed pixel is alive, 0 makes every cell alive. Defaults to 128.")
+
 	var ip string
 	var port int
 	flag.StringVar(
@@ -119,17 +234,36 @@ func main() {
 		7890,
 		"Specify the port number. Defaults to 7890.")
 
+	var slaveIp string
+	var slavePort int
+	flag.StringVar(
+		&slaveIp,
+		"sip",
+		"127.0.0.1",
+		"Specify the ip address the other slaves reach this slave on. Defaults to 127.0.0.1.")
+
+	flag.IntVar(
+		&slavePort,
+		"sp",
+		7891,
+		"Specify the port the other slaves push edges to. Defaults to 7891.")
+
 	flag.Parse()
 
+	if params.Resume != "" {
+		cp, err := gol.LoadCheckpoint(params.Resume)
+		util.Check(err)
+		params = cp.ResumeParams(params)
+	}
+
 	fmt.Println("Threads:", params.Threads)
 	fmt.Println("Width:", params.ImageWidth)
 	fmt.Println("Height:", params.ImageHeight)
 	fmt.Println("IP:", ip)
 	fmt.Println("Port:", port)
 
-	RunMasterServer(params, ip, port)
+	RunMasterServer(params, ip, port, slaveIp, slavePort)
 
-	for {
-		time.Sleep(1)
-	}
+	// wait for the events to finish, spinning here starves the other goroutines on one cpu
+	select {}
 }
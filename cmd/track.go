@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"uk.ac.bris.cs/gameoflife/census"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// RunTrack runs the Game of Life without SDL, follows the objects every turn and writes their trajectories.
+func RunTrack(params gol.Params, filename string, wrap bool) {
+	events := make(chan gol.Event, 1000)
+	gol.Run(params, events, nil, nil)
+
+	tracker := census.NewTracker(params.ImageWidth, params.ImageHeight)
+	tracker.Wrap = wrap
+	board := make([][]bool, params.ImageWidth)
+	for x := range board {
+		board[x] = make([]bool, params.ImageHeight)
+	}
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			board[e.Cell.X][e.Cell.Y] = !board[e.Cell.X][e.Cell.Y]
+		case gol.TurnComplete:
+			var alive []util.Cell
+			for x, column := range board {
+				for y, v := range column {
+					if v {
+						alive = append(alive, util.Cell{X: x, Y: y})
+					}
+				}
+			}
+			for _, te := range tracker.Update(e.CompletedTurns, alive) {
+				fmt.Println(te)
+			}
+		}
+	}
+	fmt.Print(tracker)
+
+	file, err := os.Create(filename)
+	util.Check(err)
+	defer file.Close()
+	err = tracker.WriteLog(file)
+	util.Check(err)
+	fmt.Println("Trajectories", filename, "output done!")
+}
+
+func main() {
+	var params gol.Params
+
+	flag.IntVar(
+		&params.Threads,
+		"t",
+		8,
+		"Specify the number of worker threads to use. Defaults to 8.")
+
+	flag.IntVar(
+		&params.ImageWidth,
+		"w",
+		512,
+		"Specify the width of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&params.ImageHeight,
+		"h",
+		512,
+		"Specify the height of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&params.Turns,
+		"turns",
+		100,
+		"Specify the number of turns to process. Defaults to 100.")
+
+	var filename string
+	flag.StringVar(
+		&filename,
+		"o",
+		"out/tracks.csv",
+		"Specify the trajectory log. Defaults to out/tracks.csv.")
+
+	var wrap bool
+	flag.BoolVar(
+		&wrap,
+		"wrap",
+		false,
+		"Specify following objects round the edge of the board instead of reporting them as left. Defaults to false.")
+
+	flag.Parse()
+
+	fmt.Println("Threads:", params.Threads)
+	fmt.Println("Width:", params.ImageWidth)
+	fmt.Println("Height:", params.ImageHeight)
+
+	_ = os.Mkdir("out", os.ModePerm)
+	RunTrack(params, filename, wrap)
+}
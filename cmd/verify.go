@@ -0,0 +1,303 @@
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// boards up to this size are printed side by side when they differ
+const printLimit = 64
+
+// mismatch is the difference between an output and the expected image.
+type mismatch struct {
+	extra, missing []util.Cell // alive only in the output, alive only in the expected image
+}
+
+// compare finds the cells that differ between the two boards.
+func compare(given, expected []util.Cell, width, height int) mismatch {
+	board := make([]byte, width*height)
+	for _, cell := range given {
+		board[cell.Y*width+cell.X] |= 1
+	}
+	for _, cell := range expected {
+		board[cell.Y*width+cell.X] |= 2
+	}
+	var m mismatch
+	for i, v := range board {
+		switch v {
+		case 1:
+			m.extra = append(m.extra, util.Cell{X: i % width, Y: i / width})
+		case 2:
+			m.missing = append(m.missing, util.Cell{X: i % width, Y: i / width})
+		}
+	}
+	return m
+}
+
+// writeDiff writes a png where cells alive in both are white, extra cells are red and missing cells are green.
+func writeDiff(filename string, given []util.Cell, m mismatch, width, height int) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{A: 255})
+		}
+	}
+	for _, cell := range given {
+		img.SetRGBA(cell.X, cell.Y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+	for _, cell := range m.extra {
+		img.SetRGBA(cell.X, cell.Y, color.RGBA{R: 255, A: 255})
+	}
+	for _, cell := range m.missing {
+		img.SetRGBA(cell.X, cell.Y, color.RGBA{G: 255, A: 255})
+	}
+	file, err := os.Create(filename)
+	util.Check(err)
+	defer file.Close()
+	util.Check(png.Encode(file, img))
+}
+
+// firstCells lists up to 10 cells.
+func firstCells(cells []util.Cell) string {
+	var s []string
+	for i, cell := range cells {
+		if i == 10 {
+			s = append(s, "...")
+			break
+		}
+		s = append(s, fmt.Sprintf("(%v,%v)", cell.X, cell.Y))
+	}
+	return strings.Join(s, " ")
+}
+
+// readImage reads the alive cells of a pgm, gzip compressed if it ends in .gz. A missing file
+// or one that isn't a width x height pgm is an error instead of a panic, so the other images are still checked.
+func readImage(filename string, width, height int) (cells []util.Cell, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v: %v", filename, r)
+		}
+	}()
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", filename, err)
+		}
+		r = gz
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", filename, err)
+	}
+	return util.PgmAliveCells(data, width, height), nil
+}
+
+// RunVerify runs the engine for each number of turns and compares every image it outputs
+// with expected/WxHxT.pgm. It returns the number of images that differ or couldn't be read.
+func RunVerify(params gol.Params, turns []int, expected string) int {
+	failed := 0
+	for _, t := range turns {
+		params.Turns = t
+		events := make(chan gol.Event, 1000)
+		gol.Run(params, events, nil, nil)
+
+		var outputs []gol.ImageOutputComplete
+		for event := range events {
+			// only the images, not heat maps, checkpoints or autosaves
+			if e, ok := event.(gol.ImageOutputComplete); ok && e.Filename == gol.ImageName(params, e.CompletedTurns) {
+				outputs = append(outputs, e)
+			}
+		}
+		if len(outputs) == 0 {
+			failed++
+			fmt.Println("FAIL", t, "turns")
+			fmt.Println("  no image was output")
+		}
+
+		for _, output := range outputs {
+			name := fmt.Sprintf("%vx%vx%v", params.ImageWidth, params.ImageHeight, output.CompletedTurns)
+			want, err := readImage(filepath.Join(expected, name+".pgm"), params.ImageWidth, params.ImageHeight)
+			if err != nil {
+				failed++
+				fmt.Println("FAIL", name)
+				fmt.Println("  expected image:", err)
+				continue
+			}
+			got, err := readImage(filepath.Join("out", output.Filename), params.ImageWidth, params.ImageHeight)
+			if err != nil {
+				failed++
+				fmt.Println("FAIL", name)
+				fmt.Println("  output image:", err)
+				continue
+			}
+
+			m := compare(got, want, params.ImageWidth, params.ImageHeight)
+			if len(m.extra) == 0 && len(m.missing) == 0 {
+				fmt.Println("OK  ", name)
+				continue
+			}
+			failed++
+			fmt.Println("FAIL", name)
+			fmt.Printf("  %v alive cells, expected %v\n", len(got), len(want))
+			fmt.Printf("  %v extra: %v\n", len(m.extra), firstCells(m.extra))
+			fmt.Printf("  %v missing: %v\n", len(m.missing), firstCells(m.missing))
+			if params.ImageWidth <= printLimit && params.ImageHeight <= printLimit {
+				fmt.Print(util.AliveCellsToString(got, want, params.ImageWidth, params.ImageHeight))
+			}
+			diff := filepath.Join("out", name+"-diff.png")
+			writeDiff(diff, got, m, params.ImageWidth, params.ImageHeight)
+			fmt.Println("  diff image", diff, "(red extra, green missing)")
+		}
+	}
+	return failed
+}
+
+func main() {
+	var params gol.Params
+
+	flag.IntVar(
+		&params.Threads,
+		"t",
+		8,
+		"Specify the number of worker threads to use. Defaults to 8.")
+
+	flag.IntVar(
+		&params.ImageWidth,
+		"w",
+		512,
+		"Specify the width of the image. Defaults to 512.")
+
+	flag.IntVar(
+		&params.ImageHeight,
+		"h",
+		512,
+		"Specify the height of the image. Defaults to 512.")
+
+	var turnList, expected string
+	flag.StringVar(
+		&turnList,
+		"turns",
+		"0,1,100",
+		"Specify the numbers of turns to run, separated by commas. Defaults to 0,1,100.")
+
+	flag.StringVar(
+		&expected,
+		"expected",
+		"check/images",
+		"Specify the directory of expected WxHxT.pgm images. Defaults to check/images.")
+
+	flag.StringVar(
+		&params.Resume,
+		"resume",
+		"",
+		"Specify a checkpoint file to continue from. Defaults to none.")
+
+	flag.StringVar(
+		&params.OutputTemplate,
+		"out",
+		"",
+		"Specify the output filename template, {width} {height} {turn} are replaced and a .gz suffix compresses. Defaults to {width}x{height}x{turn}.")
+
+	flag.StringVar(
+		&params.SeedImage,
+		"seed",
+		"",
+		"Specify a greyscale image (pgm, png, jpeg, gif) of any size or an rle pattern to start from. Defaults to images/WxH.pgm.")
+
+	flag.StringVar(
+		&params.SeedMode,
+		"seedMode",
+		gol.SeedThreshold,
+		"Specify how the seed becomes cells: threshold, otsu or dither. Defaults to threshold.")
+
+	flag.IntVar(
+		&params.SeedThreshold,
+		"threshold",
+		128,
+		"Specify the grey level from which a seed pixel is alive, 0 makes every cell alive. Defaults to 128.")
+
+	flag.BoolVar(
+		&params.StopWhenStable,
+		"stopStable",
+		false,
+		"Specify skipping the remaining turns once the world is static or periodic. Defaults to false.")
+
+	flag.IntVar(
+		&params.StabilityWindow,
+		"stableWindow",
+		64,
+		"Specify the longest period looked for. Defaults to 64.")
+
+	flag.IntVar(
+		&params.AutosaveTurns,
+		"autosave",
+		0,
+		"Specify autosave every n turns. Defaults to 0 (off).")
+
+	flag.BoolVar(
+		&params.AutosaveCheckpoint,
+		"autosaveCheckpoint",
+		false,
+		"Specify autosaving checkpoints to resume from instead of pgm images. Defaults to false.")
+
+	flag.BoolVar(
+		&params.Stats,
+		"stats",
+		false,
+		"Specify writing the population, births and deaths of every turn to a csv in out/. Defaults to false.")
+
+	flag.StringVar(
+		&params.HeatMap,
+		"heat",
+		"",
+		"Specify a heat map of how often cells flip (flips) or how long they stay alive (alive), written at the end. Defaults to none.")
+
+	flag.Parse()
+	util.Check(gol.CheckHeatMap(params.HeatMap))
+
+	if params.Resume != "" {
+		cp, err := gol.LoadCheckpoint(params.Resume)
+		util.Check(err)
+		params = cp.ResumeParams(params)
+	}
+
+	var turns []int
+	for _, s := range strings.Split(turnList, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		t, err := strconv.Atoi(s)
+		util.Check(err)
+		turns = append(turns, t)
+	}
+
+	fmt.Println("Threads:", params.Threads)
+	fmt.Println("Width:", params.ImageWidth)
+	fmt.Println("Height:", params.ImageHeight)
+
+	_ = os.Mkdir("out", os.ModePerm)
+	if failed := RunVerify(params, turns, expected); failed > 0 {
+		fmt.Println(failed, "images differ")
+		os.Exit(1)
+	}
+	fmt.Println("All images match")
+}
@@ -0,0 +1,52 @@
+package main
+
+// Run with go test cmd/verify.go cmd/verify_test.go, every file in cmd/ is its own program.
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+func TestVerify(t *testing.T) {
+	util.Check(os.Chdir(".."))
+	defer os.Chdir("cmd")
+	_ = os.Mkdir("out", os.ModePerm)
+
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Threads: 4}
+	if failed := RunVerify(p, []int{0, 1, 100}, "check/images"); failed != 0 {
+		t.Fatalf("%v images differ from check/images", failed)
+	}
+	p.OutputTemplate = "verify-{turn}.gz"
+	if failed := RunVerify(p, []int{1}, "check/images"); failed != 0 {
+		t.Fatalf("%v gzip compressed images differ from check/images", failed)
+	}
+	_ = os.Remove(filepath.Join("out", gol.ImageName(p, 1)))
+	p.OutputTemplate = ""
+
+	// turn 1 is the image of turn 0, turn 100 is missing and turn 0 isn't a pgm
+	dir, err := ioutil.TempDir("", "verify")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	data, err := ioutil.ReadFile("check/images/16x16x0.pgm")
+	util.Check(err)
+	util.Check(ioutil.WriteFile(filepath.Join(dir, "16x16x1.pgm"), data, 0644))
+	util.Check(ioutil.WriteFile(filepath.Join(dir, "16x16x0.pgm"), []byte("P2\n"), 0644))
+	for _, test := range []struct {
+		turns  []int
+		failed int
+	}{
+		{[]int{1}, 1},
+		{[]int{100}, 1},
+		{[]int{0}, 1},
+		{[]int{0, 1, 100}, 3},
+	} {
+		if failed := RunVerify(p, test.turns, dir); failed != test.failed {
+			t.Errorf("turns %v: %v failed, want %v", test.turns, failed, test.failed)
+		}
+	}
+}
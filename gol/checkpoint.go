@@ -0,0 +1,115 @@
+package gol
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+)
+
+// checkpointMagic starts every checkpoint file, so it can't be mixed up with a pgm.
+const checkpointMagic = "GOLCKPT1\n"
+
+// Checkpoint is the full state of a run, enough to continue it after a crash.
+type Checkpoint struct {
+	Params   Params
+	Turn     int    // completed turns
+	World    []byte // one bit per cell in panel order (x major), deflate compressed
+	Checksum uint32 // crc32 of the uncompressed World
+}
+
+// NewCheckpoint packs the panel after turn completed turns.
+func NewCheckpoint(p Params, turn int, panel [][]bool) *Checkpoint {
+	packed := make([]byte, (p.ImageWidth*p.ImageHeight+7)/8)
+	for x := 0; x < p.ImageWidth; x++ {
+		for y := 0; y < p.ImageHeight; y++ {
+			if panel[x][y] {
+				i := x*p.ImageHeight + y
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestSpeed)
+	_, _ = w.Write(packed)
+	_ = w.Close()
+
+	return &Checkpoint{
+		Params:   p,
+		Turn:     turn,
+		World:    buf.Bytes(),
+		Checksum: crc32.ChecksumIEEE(packed),
+	}
+}
+
+// Panel unpacks the world, it fails if the checksum doesn't match.
+func (cp *Checkpoint) Panel() ([][]bool, error) {
+	packed, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(cp.World)))
+	if err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(packed) != cp.Checksum {
+		return nil, errors.New("checkpoint checksum mismatch")
+	}
+	width, height := cp.Params.ImageWidth, cp.Params.ImageHeight
+	if len(packed) != (width*height+7)/8 {
+		return nil, fmt.Errorf("checkpoint world is %v bytes, expected %vx%v cells", len(packed), width, height)
+	}
+
+	panel := make([][]bool, width)
+	for x := range panel {
+		panel[x] = make([]bool, height)
+		for y := range panel[x] {
+			i := x*height + y
+			panel[x][y] = packed[i/8]&(1<<uint(i%8)) != 0
+		}
+	}
+	return panel, nil
+}
+
+// ResumeParams returns p with the board and turns taken from the checkpoint.
+// Everything about how to run (threads, autosave...) is left as given.
+func (cp *Checkpoint) ResumeParams(p Params) Params {
+	p.ImageWidth = cp.Params.ImageWidth
+	p.ImageHeight = cp.Params.ImageHeight
+	p.Turns = cp.Params.Turns
+	return p
+}
+
+// WriteCheckpoint encodes cp to w.
+func WriteCheckpoint(w io.Writer, cp *Checkpoint) error {
+	if _, err := io.WriteString(w, checkpointMagic); err != nil {
+		return err
+	}
+	return gob.NewEncoder(w).Encode(cp)
+}
+
+// ReadCheckpoint decodes a checkpoint written by WriteCheckpoint.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != checkpointMagic {
+		return nil, errors.New("not a checkpoint file")
+	}
+	var cp Checkpoint
+	if err := gob.NewDecoder(br).Decode(&cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// LoadCheckpoint reads the checkpoint file at path, which may be gzip compressed.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return ReadCheckpoint(in)
+}
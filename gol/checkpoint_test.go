@@ -0,0 +1,90 @@
+package gol
+
+import (
+	"bytes"
+	"compress/flate"
+	"math/rand"
+	"testing"
+)
+
+// TestCheckpointRoundTrip writes checkpoints of random worlds and reads them back.
+func TestCheckpointRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range [][2]int{{16, 16}, {7, 13}, {512, 512}, {1, 1}} {
+		p := Params{ImageWidth: size[0], ImageHeight: size[1], Turns: 1000, Threads: 3}
+		panel := randomPanel(size[0], size[1], 0.3, r)
+		var buf bytes.Buffer
+		if err := WriteCheckpoint(&buf, NewCheckpoint(p, 123, panel)); err != nil {
+			t.Fatal(err)
+		}
+
+		cp, err := ReadCheckpoint(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cp.Turn != 123 || cp.Params != p {
+			t.Fatalf("read turn %v params %+v, want 123 %+v", cp.Turn, cp.Params, p)
+		}
+		got, err := cp.Panel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		equalPanels(t, got, panel)
+
+		resumed := cp.ResumeParams(Params{ImageWidth: 64, ImageHeight: 64, Threads: 8})
+		if resumed.ImageWidth != size[0] || resumed.ImageHeight != size[1] || resumed.Turns != 1000 || resumed.Threads != 8 {
+			t.Fatalf("resumed with %+v", resumed)
+		}
+	}
+}
+
+// TestCheckpointCorrupt checks a world that doesn't match its crc, or isn't a checkpoint, is refused.
+func TestCheckpointCorrupt(t *testing.T) {
+	p := Params{ImageWidth: 16, ImageHeight: 16}
+	panel := randomPanel(16, 16, 0.5, rand.New(rand.NewSource(2)))
+
+	cp := NewCheckpoint(p, 5, panel)
+	cp.Checksum ^= 1
+	if _, err := cp.Panel(); err == nil {
+		t.Fatal("unpacked a world with the wrong checksum")
+	}
+
+	// a cell flipped in the world, compressed again so only the crc can tell
+	cp = NewCheckpoint(p, 5, panel)
+	panel[3][4] = !panel[3][4]
+	var world bytes.Buffer
+	w, _ := flate.NewWriter(&world, flate.BestSpeed)
+	_, _ = w.Write(packedPanel(panel))
+	_ = w.Close()
+	cp.World = world.Bytes()
+	if _, err := cp.Panel(); err == nil {
+		t.Fatal("unpacked a world changed after its checksum")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCheckpoint(&buf, NewCheckpoint(p, 5, panel)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if _, err := ReadCheckpoint(bytes.NewReader(data[:len(data)/2])); err == nil {
+		t.Fatal("read half a checkpoint")
+	}
+	if _, err := ReadCheckpoint(bytes.NewReader([]byte("P5\n16 16\n255\n"))); err == nil {
+		t.Fatal("read a pgm as a checkpoint")
+	}
+}
+
+// packedPanel is the world packed as NewCheckpoint does, before compressing.
+func packedPanel(panel [][]bool) []byte {
+	height := len(panel[0])
+	packed := make([]byte, (len(panel)*height+7)/8)
+	for x := range panel {
+		for y := range panel[x] {
+			if panel[x][y] {
+				i := x*height + y
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+	}
+	return packed
+}
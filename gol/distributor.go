@@ -2,6 +2,7 @@ package gol
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
 	"time"
 
@@ -13,8 +14,13 @@ type distributorChannels struct {
 	ioCommand  chan<- ioCommand
 	ioIdle     <-chan bool
 	filename   chan<- string
-	output     chan<- uint8
-	input      <-chan uint8
+	output     chan<- []byte
+	input      <-chan []byte
+
+	checkpointOutput chan<- *Checkpoint
+	checkpointInput  <-chan *Checkpoint
+	statsOutput      chan<- TurnStats
+
 	keyPresses <-chan rune
 	hc         *MSCtrl
 }
@@ -53,26 +59,68 @@ func distributor(p Params, c distributorChannels) {
 		panel[i] = make([]bool, p.ImageHeight)
 	}
 
-	// load init cells
-	c.ioCommand <- ioInput
-	c.filename <- fmt.Sprintf("%vx%v", p.ImageWidth, p.ImageHeight)
-
+	turn := 0
 	var initCells = make([]util.Cell, 0)
-	for y := 0; y < p.ImageHeight; y++ {
-		for x := 0; x < p.ImageWidth; x++ {
-			val := <-c.input
-			if val == 255 {
-				panel[x][y] = true
-				initCells = append(initCells, util.Cell{X: x, Y: y})
+	if c.hc != nil && !p.IsMaster {
+		// a slave gets its strip from the master, which may have resumed from a checkpoint
+	} else if p.Resume != "" {
+		// continue from a checkpoint
+		c.ioCommand <- ioCheckpointInput
+		c.filename <- p.Resume
+		cp := <-c.checkpointInput
+		if cp.Params.ImageWidth != p.ImageWidth || cp.Params.ImageHeight != p.ImageHeight {
+			panic("Checkpoint size doesn't match")
+		}
+		world, err := cp.Panel()
+		util.Check(err)
+		panel = world
+		turn = cp.Turn
+		for x := range panel {
+			for y := range panel[x] {
+				if panel[x][y] {
+					initCells = append(initCells, util.Cell{X: x, Y: y})
+				}
+			}
+		}
+	} else {
+		// load init cells
+		if p.SeedImage != "" {
+			c.ioCommand <- ioSeedInput
+			c.filename <- p.SeedImage
+		} else {
+			c.ioCommand <- ioInput
+			c.filename <- fmt.Sprintf("%vx%v", p.ImageWidth, p.ImageHeight)
+		}
+
+		for y := 0; y < p.ImageHeight; y++ {
+			row := <-c.input
+			for x, val := range row {
+				if val == 255 {
+					panel[x][y] = true
+					initCells = append(initCells, util.Cell{X: x, Y: y})
+				}
 			}
 		}
 	}
 
+	// watch for the world repeating itself
+	stability := newStabilityDetector(p)
+	stableTurn := -1 // the turn the world at p.Turns is reached, when stopping early
+
+	// births, deaths and population of every turn
+	stats := newStatsCollector(p)
+	// how active each cell is
+	heat := newHeatMap(p)
+
 	// For all initially alive cells send a CellFlipped Event.
 	for _, cell := range initCells {
-		c.events <- CellFlipped{CompletedTurns: 0, Cell: cell}
+		stability.flip(cell)
+		stats.born(cell)
+		heat.load(cell, turn)
+		c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
 	}
-	c.events <- TurnComplete{CompletedTurns: 0}
+	stats.reset()
+	c.events <- TurnComplete{CompletedTurns: turn}
 	// Execute all turns of the Game of Life.
 
 	maxWidth := p.ImageWidth - 1
@@ -107,6 +155,52 @@ func distributor(p Params, c distributorChannels) {
 		return false
 	}
 
+	// nextCells checks the cells of r on p.Threads workers, it returns
+	// the cells that die and the cells that come alive, the panel isn't changed
+	var nextCells = func(r rect) ([]util.Cell, []util.Cell) {
+		var checkRow = make(chan int)
+		go func() {
+			for i := r.x0; i < r.x1; i++ {
+				checkRow <- i
+			}
+			close(checkRow)
+		}()
+
+		threads := p.Threads
+		if threads < 1 {
+			threads = 1
+		}
+		dies := make([][]util.Cell, threads)
+		lives := make([][]util.Cell, threads)
+		var wg sync.WaitGroup
+		for i := 0; i < threads; i++ {
+			wg.Add(1)
+			go func(i int) {
+				for index := range checkRow {
+					for j := r.y0; j < r.y1; j++ {
+						oldLive := panel[index][j]
+						live := checkOneCell(index, j)
+						if oldLive && !live {
+							dies[i] = append(dies[i], util.Cell{X: index, Y: j})
+						}
+						if !oldLive && live {
+							lives[i] = append(lives[i], util.Cell{X: index, Y: j})
+						}
+					}
+				}
+				wg.Done()
+			}(i)
+		}
+		wg.Wait()
+
+		var newDieCells, newLiveCells []util.Cell
+		for i := 0; i < threads; i++ {
+			newDieCells = append(newDieCells, dies[i]...)
+			newLiveCells = append(newLiveCells, lives[i]...)
+		}
+		return newDieCells, newLiveCells
+	}
+
 	// read alive cells
 	var getAliveCells = func() []util.Cell {
 		var alive []util.Cell
@@ -120,30 +214,141 @@ func distributor(p Params, c distributorChannels) {
 		return alive
 	}
 
-	var writePanel = func(t int) {
+	// the io goroutine is shared by the turn loop and the keyPresses goroutine
+	var ioLock sync.Mutex
+	// panelLock keeps the panel and turn consistent for the other goroutines
+	var panelLock sync.Mutex
+
+	// label the connected alive cells
+	var clusters = func() ClustersFound {
+		distance := p.ClusterDistance
+		if distance <= 0 {
+			distance = 1
+		}
+		panelLock.Lock()
+		defer panelLock.Unlock()
+		return ClustersFound{CompletedTurns: turn, Clusters: util.Clusters(getAliveCells(), p.ImageWidth, p.ImageHeight, distance)}
+	}
+
+	var writePanel = func(filename string, t int) {
+		ioLock.Lock()
+		defer ioLock.Unlock()
 		// write image
 		c.ioCommand <- ioOutput
-		c.filename <- fmt.Sprintf("%vx%vx%v", p.ImageWidth, p.ImageHeight, t)
+		c.filename <- filename
+		panelLock.Lock()
 		for y := 0; y < p.ImageHeight; y++ {
-			for x := 0; x < p.ImageWidth; x++ {
+			row := make([]byte, p.ImageWidth)
+			for x := range row {
 				if panel[x][y] {
-					c.output <- 255
-				} else {
-					c.output <- 0
+					row[x] = 255
 				}
 			}
+			c.output <- row
 		}
+		panelLock.Unlock()
+		// wait the file is on disk
+		c.ioCommand <- ioCheckIdle
+		<-c.ioIdle
+		c.events <- ImageOutputComplete{CompletedTurns: t, Filename: outputName(filename, ".pgm")}
+	}
+
+	var writeCheckpoint = func(filename string, t int) {
+		ioLock.Lock()
+		defer ioLock.Unlock()
+		c.ioCommand <- ioCheckpointOutput
+		c.filename <- filename
+		panelLock.Lock()
+		cp := NewCheckpoint(p, t, panel)
+		panelLock.Unlock()
+		c.checkpointOutput <- cp
+		c.ioCommand <- ioCheckIdle
+		<-c.ioIdle
+		c.events <- ImageOutputComplete{CompletedTurns: t, Filename: outputName(filename, ".ckpt")}
+	}
+
+	var panelName = func(t int) string {
+		return templateName(p, t)
+	}
+
+	var writeHeatMap = func(t int) {
+		ioLock.Lock()
+		defer ioLock.Unlock()
+		filename := outputName(panelName(t), "-heat")
+		c.ioCommand <- ioHeatMapOutput
+		c.filename <- filename
+		panelLock.Lock()
+		rows := heat.rows(panel, t)
+		panelLock.Unlock()
+		for _, row := range rows {
+			c.output <- row
+		}
+		c.ioCommand <- ioCheckIdle
+		<-c.ioIdle
+		ext := ".pgm"
+		if p.HeatMapColour {
+			ext = ".png"
+		}
+		c.events <- ImageOutputComplete{CompletedTurns: t, Filename: outputName(filename, ext)}
+	}
+
+	// autosave, keep the last p.AutosaveKeep files
+	var autosaves []string
+	lastAutosave := time.Now()
+	// an autosave is due if a multiple of p.AutosaveTurns is in (from, to], or the interval passed
+	var autosaveDue = func(from, to int) bool {
+		return p.AutosaveTurns > 0 && to/p.AutosaveTurns > from/p.AutosaveTurns ||
+			p.AutosaveInterval > 0 && time.Since(lastAutosave) >= p.AutosaveInterval
+	}
+	var autosave = func(t int) {
+		filename := outputName(panelName(t), "-autosave")
+		if p.AutosaveCheckpoint {
+			writeCheckpoint(filename, t)
+			autosaves = append(autosaves, outputName(filename, ".ckpt"))
+		} else {
+			writePanel(filename, t)
+			autosaves = append(autosaves, outputName(filename, ".pgm"))
+		}
+		lastAutosave = time.Now()
+		if p.AutosaveKeep > 0 && len(autosaves) > p.AutosaveKeep {
+			ioLock.Lock()
+			c.ioCommand <- ioRemove
+			c.filename <- autosaves[0]
+			ioLock.Unlock()
+			autosaves = autosaves[1:]
+		}
+	}
+
+	// the master's world is missing strips while the slaves resend them, wait for them first,
+	// an error is the world isn't whole
+	var syncPanel = func() error {
+		if c.hc != nil && p.IsMaster {
+			return c.hc.Server.requestState(BarrierTimeout)
+		}
+		return nil
 	}
 
-	turn := 0
 	runExit := false
 	pause := false
+	// the slaves waiting at the barrier look at runExit and pause again
+	var wake = func() {
+		if c.hc != nil && p.IsMaster {
+			c.hc.Server.wake()
+		}
+	}
 	// report AliveCellsCount
 	if c.hc == nil || p.IsMaster {
 		go func() {
 			for range time.Tick(2 * time.Second) {
 				if !runExit {
-					c.events <- AliveCellsCount{CompletedTurns: turn, CellsCount: len(getAliveCells())}
+					if err := syncPanel(); err != nil {
+						fmt.Println("alive cells:", err)
+						continue
+					}
+					panelLock.Lock()
+					count := AliveCellsCount{CompletedTurns: turn, CellsCount: len(getAliveCells())}
+					panelLock.Unlock()
+					c.events <- count
 				}
 			}
 		}()
@@ -153,21 +358,39 @@ func distributor(p Params, c distributorChannels) {
 	if c.hc != nil && p.IsMaster {
 		handle := &MasterHandle{}
 		handle.OnTurnComplete = func(t int) {
-			turn++
-			c.events <- TurnComplete{CompletedTurns: turn}
+			panelLock.Lock()
+			turn = t
+			panelLock.Unlock()
+			c.events <- TurnComplete{CompletedTurns: t}
 		}
-		handle.OnSlaveFinish = func(points []Point) {
-			for _, p := range points {
-				panel[p.cell.X][p.cell.Y] = p.value
+		handle.OnFlipped = func(t int, cells []util.Cell) {
+			panelLock.Lock()
+			for _, cell := range cells {
+				panel[cell.X][cell.Y] = !panel[cell.X][cell.Y]
 			}
+			panelLock.Unlock()
+		}
+		handle.OnSlaveFinish = func(strip Strip) {
+			panelLock.Lock()
+			// the master checked the strip when it came, an error here is a bug
+			if err := strip.unpack(panel); err != nil {
+				fmt.Println("slave finish:", err)
+			}
+			panelLock.Unlock()
 		}
 		handle.CheckExit = func() bool {
 			return runExit
 		}
-		handle.GetByIndex = func(cell util.Cell) Point {
-			return Point{cell: cell, value: panel[cell.X][cell.Y]}
+		handle.CheckPause = func() bool {
+			return pause
+		}
+		handle.GetStrip = func(id SlaveId) Strip {
+			panelLock.Lock()
+			defer panelLock.Unlock()
+			return packStrip(panel, id.RowStart, id.ColStart, id.RowEnd-id.RowStart, id.ColEnd-id.ColStart, p.CompressStrips)
 		}
 		c.hc.Server.setHandle(handle)
+		c.hc.Server.setTurn(turn)
 	}
 
 	// master or single
@@ -181,14 +404,38 @@ func distributor(p Params, c distributorChannels) {
 				ctl := <-c.keyPresses
 				switch ctl {
 				case 's':
-					writePanel(turn)
+					if err := syncPanel(); err != nil {
+						fmt.Println("Save failed:", err)
+						break
+					}
+					writePanel(panelName(turn), turn)
+					writeCheckpoint(panelName(turn), turn)
 					fmt.Println("Save Success")
 				case 'q':
-					writePanel(turn)
+					if err := syncPanel(); err != nil {
+						fmt.Println("Exit without saving:", err)
+					} else {
+						writePanel(panelName(turn), turn)
+						writeCheckpoint(panelName(turn), turn)
+					}
 					runExit = true
+					wake()
 					fmt.Println("Exit")
+				case 'c':
+					if err := syncPanel(); err != nil {
+						fmt.Println("Clusters:", err)
+						break
+					}
+					c.events <- clusters()
+				case 'h':
+					if p.HeatMap != "" {
+						writeHeatMap(turn)
+					} else {
+						fmt.Println("No heat map, set -heat")
+					}
 				case 'p':
 					pause = !pause
+					wake()
 					if pause {
 						fmt.Println("Current running turn is ", turn)
 					} else {
@@ -214,57 +461,73 @@ func distributor(p Params, c distributorChannels) {
 			*/
 
 			// 1. check all alive cells && there neighbour
-			var checkRow = make(chan int)
-			var newDieCells = make(chan util.Cell, 10000)
-			var newLiveCells = make(chan util.Cell, 10000)
-
-			go func() {
-				for i := 0; i < p.ImageWidth; i++ {
-					checkRow <- i
-				}
-				close(checkRow)
-			}()
-
-			var wg sync.WaitGroup
-			for i := 0; i < p.Threads; i++ {
-				wg.Add(1)
-				go func() {
-					for index := range checkRow {
-						for j := 0; j < p.ImageHeight; j++ {
-							oldLive := panel[index][j]
-							live := checkOneCell(index, j)
-							if oldLive && !live {
-								newDieCells <- util.Cell{X: index, Y: j}
-							}
-							if !oldLive && live {
-								newLiveCells <- util.Cell{X: index, Y: j}
-							}
-						}
-					}
-					wg.Done()
-				}()
-			}
-
-			wg.Wait()
-			close(newDieCells)
-			close(newLiveCells)
+			newDieCells, newLiveCells := nextCells(rect{0, p.ImageWidth, 0, p.ImageHeight})
+			panelLock.Lock()
 			turn++
 			// wait result
-			for cell := range newDieCells {
+			for _, cell := range newDieCells {
 				panel[cell.X][cell.Y] = false
+				stability.flip(cell)
+				stats.died(cell)
+				heat.flip(cell, false, turn)
 				c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
 			}
-			for cell := range newLiveCells {
+			for _, cell := range newLiveCells {
 				panel[cell.X][cell.Y] = true
+				stability.flip(cell)
+				stats.born(cell)
+				heat.flip(cell, true, turn)
 				c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
 			}
+			panelLock.Unlock()
 
+			if p.Stats || p.StatsEvents {
+				turnStats := stats.turnComplete(turn)
+				if p.Stats {
+					// the io goroutine adds the row to the stats csv
+					ioLock.Lock()
+					c.ioCommand <- ioStatsRow
+					c.statsOutput <- turnStats
+					ioLock.Unlock()
+				}
+				if p.StatsEvents {
+					c.events <- turnStats
+				}
+			}
 			c.events <- TurnComplete{CompletedTurns: turn}
+			if autosaveDue(turn-1, turn) {
+				autosave(turn)
+			}
+
+			if period, first, ok := stability.turnComplete(turn); ok {
+				c.events <- StabilityReached{CompletedTurns: turn, Period: period, FirstRepeat: first}
+				if p.StopWhenStable {
+					// the world at p.Turns is the world (p.Turns-turn)%period turns from now
+					stableTurn = turn + (p.Turns-turn)%period
+				}
+			}
+			if turn == stableTurn && turn < p.Turns {
+				fmt.Println("Stable, skip from turn", turn, "to", p.Turns)
+				panelLock.Lock()
+				turn = p.Turns
+				panelLock.Unlock()
+				c.events <- TurnComplete{CompletedTurns: turn}
+			}
 		}
 		// not quit
 		if !runExit {
 			// write image
-			writePanel(p.Turns)
+			writePanel(panelName(p.Turns), p.Turns)
+		}
+		if p.Stats {
+			// name the stats of the turns that were run
+			ioLock.Lock()
+			c.ioCommand <- ioStatsOutput
+			c.filename <- outputName(panelName(turn), "-stats")
+			ioLock.Unlock()
+		}
+		if p.HeatMap != "" {
+			writeHeatMap(turn)
 		}
 		// send FinalTurnComplete
 		alive := getAliveCells()
@@ -273,73 +536,204 @@ func distributor(p Params, c distributorChannels) {
 	}
 
 	// ms model
-	if c.hc	!= nil{
+	if c.hc != nil {
 		if p.IsMaster {
-			for {
-				time.Sleep(1)
+			// the slaves run the turns, save when needed and finish at p.Turns
+			lastTurn := turn
+			for !runExit {
+				t := c.hc.Server.waitTurn(lastTurn, HeartbeatInterval)
+				if t >= p.Turns {
+					if err := syncPanel(); err != nil {
+						// the slaves resend their strips once they are back at the barrier
+						fmt.Println("final turn:", err)
+						continue
+					}
+					writePanel(panelName(turn), turn)
+					c.events <- FinalTurnComplete{CompletedTurns: turn, Alive: getAliveCells()}
+					runExit = true
+					wake()
+					break
+				}
+				if t == lastTurn {
+					continue
+				}
+				if autosaveDue(lastTurn, t) {
+					if err := syncPanel(); err != nil {
+						fmt.Println("autosave:", err)
+					} else {
+						autosave(turn)
+					}
+				}
+				lastTurn = t
+			}
+			// let the slaves see the exit
+			c.hc.Server.waitExited(5 * time.Second)
+			for _, w := range c.hc.Server.barrierWaits() {
+				fmt.Println(w)
 			}
 		} else {
-			var myTurn = 0
-			config := c.hc.Client.FetchMyConfig()
-			for {
-				cnp := &CheckNextTurnParam{Id: config.Id}
+			stopHeartbeat := make(chan bool)
+			go c.hc.Client.Heartbeat(c.hc.Address, stopHeartbeat)
+
+			// the strip and the turn come from the master, again whenever the strips change
+			var config *SlaveConfigResponse
+			var turnTime time.Duration
+			// the workers this slave can run at once
+			capacity := p.Threads
+			if capacity < 1 || capacity > runtime.NumCPU() {
+				capacity = runtime.NumCPU()
+			}
+			// the cells flipped in the last turn and the checksum go with the next CheckNextTurn
+			var flipped []util.Cell
+			var checksum uint64
+			sent := true
+			// a neighbour the edges couldn't be pushed to, for the master to check
+			unreachable := ""
+			// the turns the cells around the tile are good for, the edges are swapped at 0
+			ghosts := 0
+			var fetchConfig = func() {
+				for {
+					config = c.hc.Client.FetchMyConfig(&SlaveConfigParam{Address: c.hc.Address, Capacity: capacity})
+					if !config.Wait {
+						break
+					}
+					time.Sleep(HeartbeatInterval)
+				}
+				c.hc.Slave.setEpoch(config.Epoch)
+				util.Check(config.State.unpack(panel))
+				turn = config.Turn
+				turnTime = 0
+				sent = true
+				ghosts = 0
+			}
+			fetchConfig()
+			lastRequest := 0
+			peers := make(map[string]*GolPeerClient)
+			var strip = func(r rect) Strip {
+				return packStrip(panel, r.x0, r.y0, r.x1-r.x0, r.y1-r.y0, config.Params.CompressStrips)
+			}
+			var push = func(address string, to SlaveId, depth int) bool {
+				if peers[address] == nil {
+					peer, err := NewGolPeerClient(address)
+					if err != nil {
+						fmt.Println("push edges:", err)
+						return false
+					}
+					peers[address] = peer
+				}
+				edges := &EdgesParam{Epoch: config.Epoch, Turn: turn, From: config.Id}
+				for _, r := range halo(to, config.Id, depth, p.ImageWidth, p.ImageHeight) {
+					edges.Edges = append(edges.Edges, strip(r))
+				}
+				err := peers[address].PushEdges(edges)
+				if err != nil {
+					// the slave may be gone, the master will tell
+					fmt.Println("push edges:", err)
+					delete(peers, address)
+					return false
+				}
+				return true
+			}
+			for !config.Exit {
+				cnp := &CheckNextTurnParam{Id: config.Id, Turn: turn, Epoch: config.Epoch, TurnTime: turnTime, StateRequest: lastRequest, Unreachable: unreachable}
+				if !sent {
+					cnp.Sent = true
+					cnp.Flipped = flipped
+					cnp.Checksum = checksum
+				}
 				cnr := c.hc.Client.CheckNextTurn(cnp)
-				if cnr.Exit{
+				turnTime = 0
+				sent = true
+				unreachable = ""
+				if cnr.Exit {
 					break
 				}
-				if !cnr.AllReady{
+				if cnr.Reconfigure {
+					fetchConfig()
 					continue
 				}
-				// calc my turn
-				np := &NextTurnParam{Id: config.Id}
-				nr := c.hc.Client.FetchNextTurn(np)
-				myTurn = nr.Turn
-				// load the edge
-				for _, p := range nr.Edges{
-					panel[p.cell.X][p.cell.Y] = p.value
+				if cnr.WantState && cnr.StateRequest != lastRequest {
+					// report my state
+					lastRequest = cnr.StateRequest
+					rp := &ReportParam{
+						Id:      config.Id,
+						Turn:    turn,
+						Epoch:   config.Epoch,
+						MyState: strip(tileRect(config.Id)),
+					}
+					if err := c.hc.Client.ReportMyState(rp); err != nil {
+						fmt.Println("report state:", err)
+					}
 				}
-				// check my panel
-				var newDieCells = make([]util.Cell, 0, 1000)
-				var newLiveCells = make([]util.Cell, 0, 1000)
-
-				for i:= config.Id.RowStart; i < config.Id.RowEnd; i++{
-					for j := 0; j < p.ImageHeight; j++ {
-						oldLive := panel[i][j]
-						live := checkOneCell(i, j)
-						if oldLive && !live {
-							newDieCells = append(newDieCells, util.Cell{X: i, Y: j})
-
+				if !cnr.AllReady {
+					continue
+				}
+				if ghosts == 0 {
+					// swap the edges with the tiles around, corners too, deep enough to
+					// reach the next multiple of the depth so every slave swaps at the same turns
+					depth := haloDepth(config.Params)
+					depth -= turn % depth
+					var from []SlaveId
+					for slaveId, address := range cnr.Neighbours {
+						if !push(address, slaveId, depth) {
+							// the master waits before letting this slave try again
+							unreachable = address
+							break
 						}
-						if !oldLive && live {
-							newLiveCells = append(newLiveCells, util.Cell{X: i, Y: j})
+						from = append(from, slaveId)
+					}
+					if unreachable != "" {
+						continue
+					}
+					edges, ok := c.hc.Slave.waitEdges(turn, from, HeartbeatInterval)
+					if !ok {
+						continue
+					}
+					for _, edge := range edges {
+						// checked when they were pushed
+						if err := edge.unpack(panel); err != nil {
+							fmt.Println("edges:", err)
 						}
 					}
+					ghosts = depth
 				}
 
+				// check my panel on the workers of this machine, with the cells around it
+				// still good for the turns after, they shrink by a cell each turn
+				start := time.Now()
+				var newDieCells, newLiveCells []util.Cell
+				for _, r := range grow(tileRect(config.Id), ghosts-1, p.ImageWidth, p.ImageHeight) {
+					dies, lives := nextCells(r)
+					newDieCells = append(newDieCells, dies...)
+					newLiveCells = append(newLiveCells, lives...)
+				}
+				turnTime = time.Since(start)
+
+				turn++
+				ghosts--
+				mine := tileRect(config.Id)
+				flipped = nil
 				for _, cell := range newDieCells {
 					panel[cell.X][cell.Y] = false
-					c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
+					if mine.contains(cell.X, cell.Y) {
+						flipped = append(flipped, cell)
+						c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
+					}
 				}
 				for _, cell := range newLiveCells {
 					panel[cell.X][cell.Y] = true
-					c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
-				}
-
-				// report my state
-				rp := &ReportParam{
-					Id:      config.Id,
-					Turn:    myTurn,
-				}
-				for i:= config.Id.RowStart; i < config.Id.RowEnd; i++{
-					for j := 0; j < p.ImageHeight; j++ {
-						rp.MyState = append(rp.MyState, Point{
-							cell:  util.Cell{X: i, Y: j},
-							value: panel[i][j],
-						})
+					if mine.contains(cell.X, cell.Y) {
+						flipped = append(flipped, cell)
+						c.events <- CellFlipped{CompletedTurns: turn, Cell: cell}
 					}
 				}
-				c.hc.Client.ReportMyState(rp)
+				checksum = 0
+				if config.Params.ChecksumTurns > 0 && turn%config.Params.ChecksumTurns == 0 {
+					checksum = strip(tileRect(config.Id)).checksum()
+				}
+				sent = false
 			}
+			close(stopHeartbeat)
 		}
 
 	}
@@ -67,6 +67,34 @@ type FinalTurnComplete struct {
 	Alive          []util.Cell
 }
 
+// StabilityReached is an Event notifying the user that the world has started repeating itself.
+// Period is 1 for a static world, FirstRepeat is the first turn of the cycle.
+// This Event is sent once, the turn the cycle is confirmed, a period after it is first seen.
+type StabilityReached struct {
+	CompletedTurns int
+	Period         int
+	FirstRepeat    int
+}
+
+// TurnStats is an Event with the statistics of a completed turn, sent when Params.StatsEvents is set.
+// MinX, MinY, MaxX and MaxY bound the alive cells, they are -1 when there are none.
+// SDL ignores this Event.
+type TurnStats struct {
+	CompletedTurns int
+	Alive          int
+	Births         int
+	Deaths         int
+	MinX, MinY     int
+	MaxX, MaxY     int
+}
+
+// ClustersFound is an Event with the clusters of connected alive cells, sent when 'c' is pressed.
+// Cells are connected up to Params.ClusterDistance apart, see util.Clusters.
+type ClustersFound struct {
+	CompletedTurns int
+	Clusters       []util.Cluster
+}
+
 // String methods allow the different types of Events and States to be printed.
 
 func (state State) String() string {
@@ -130,6 +158,39 @@ func (event FinalTurnComplete) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event StabilityReached) String() string {
+	if event.Period == 1 {
+		return fmt.Sprintf("Static since turn %v", event.FirstRepeat)
+	}
+	return fmt.Sprintf("Period %v since turn %v", event.Period, event.FirstRepeat)
+}
+
+func (event StabilityReached) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event ClustersFound) String() string {
+	largest := 0
+	for _, cluster := range event.Clusters {
+		if cluster.Size > largest {
+			largest = cluster.Size
+		}
+	}
+	return fmt.Sprintf("%v clusters, the largest has %v cells", len(event.Clusters), largest)
+}
+
+func (event ClustersFound) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event TurnStats) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event TurnStats) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 // This might all seem like weird syntax to you...
 // You have however seen something similar to it before in first year.
 
@@ -1,5 +1,7 @@
 package gol
 
+import "time"
+
 // Params provides the details of how to run the Game of Life and which image to load.
 type Params struct {
 	Turns       int
@@ -8,6 +10,41 @@ type Params struct {
 	ImageHeight int
 	IsMaster    bool
 	SlaveCount  int
+
+	RebalanceTurns int  // the master checks every n turns whether a slave is slower than the others, 0 is off
+	CompressStrips bool // run length encode the strips sent between the master and the slaves
+	ChecksumTurns  int  // the slaves send a checksum of their strip every n turns, 0 is off
+	TileRows       int  // the world is split into this many rows of tiles, 1 is strips
+	HaloDepth      int  // the slaves swap edges this many cells deep and compute that many turns between, 1 is every turn
+
+	AutosaveTurns      int           // autosave every n turns, 0 is off
+	AutosaveInterval   time.Duration // autosave every interval, 0 is off
+	AutosaveKeep       int           // keep the last n autosaves, 0 keeps all
+	AutosaveCheckpoint bool          // autosave checkpoints to resume from instead of pgm images
+
+	Resume string // checkpoint file to continue from, empty starts from the image
+
+	// OutputTemplate names the files in out/, {width} {height} and {turn} are replaced.
+	// A .gz suffix gzip compresses every output. Empty is "{width}x{height}x{turn}".
+	OutputTemplate string
+
+	// SeedImage is any greyscale image (pgm, png, jpeg, gif) to start from instead of
+	// images/WxH.pgm, it's resampled to the board and converted by SeedMode.
+	// It can also be an rle pattern, which is put in the middle of the board.
+	SeedImage     string
+	SeedMode      string // SeedThreshold, SeedOtsu or SeedDither, empty is SeedThreshold
+	SeedThreshold int    // for SeedThreshold, 0 to 255, negative is 128
+
+	StabilityWindow int  // generations remembered to find a static or periodic world, 0 is 64, single mode only
+	StopWhenStable  bool // skip the remaining turns once the world is periodic, single mode only
+
+	Stats       bool // write the births, deaths and population of every turn to a csv in out/
+	StatsEvents bool // send a TurnStats event after every turn
+
+	ClusterDistance int // cells this far apart are in the same cluster for the 'c' key, 0 is 1 (8 neighbours)
+
+	HeatMap       string // HeatMapFlips or HeatMapAlive, written at the end and when 'h' is pressed, empty is off
+	HeatMapColour bool   // a false colour png instead of a greyscale pgm
 }
 
 // Run starts the processing of Game of Life. It should initialise channels and goroutines.
@@ -16,8 +53,11 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune, hc *MSCtrl) {
 	ioCommand := make(chan ioCommand)
 	ioIdle := make(chan bool)
 	filename := make(chan string)
-	output := make(chan uint8)
-	input := make(chan uint8)
+	output := make(chan []byte)
+	input := make(chan []byte)
+	checkpointOutput := make(chan *Checkpoint)
+	checkpointInput := make(chan *Checkpoint)
+	statsOutput := make(chan TurnStats)
 
 	distributorChannels := distributorChannels{
 		events,
@@ -26,6 +66,9 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune, hc *MSCtrl) {
 		filename,
 		output,
 		input,
+		checkpointOutput,
+		checkpointInput,
+		statsOutput,
 		keyPresses,
 		hc,
 	}
@@ -37,6 +80,10 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune, hc *MSCtrl) {
 		filename: filename,
 		output:   output,
 		input:    input,
+
+		checkpointOutput: checkpointOutput,
+		checkpointInput:  checkpointInput,
+		statsOutput:      statsOutput,
 	}
 	go startIo(p, ioChannels)
 }
@@ -0,0 +1,91 @@
+package gol
+
+// rect is the cells x0 to x1 and y0 to y1 (not containing x1 and y1), it doesn't wrap.
+type rect struct {
+	x0, x1, y0, y1 int
+}
+
+func (r rect) empty() bool {
+	return r.x0 >= r.x1 || r.y0 >= r.y1
+}
+
+func (r rect) contains(x, y int) bool {
+	return x >= r.x0 && x < r.x1 && y >= r.y0 && y < r.y1
+}
+
+func (r rect) intersect(s rect) rect {
+	if s.x0 > r.x0 {
+		r.x0 = s.x0
+	}
+	if s.x1 < r.x1 {
+		r.x1 = s.x1
+	}
+	if s.y0 > r.y0 {
+		r.y0 = s.y0
+	}
+	if s.y1 < r.y1 {
+		r.y1 = s.y1
+	}
+	return r
+}
+
+func tileRect(id SlaveId) rect {
+	return rect{id.RowStart, id.RowEnd, id.ColStart, id.ColEnd}
+}
+
+// spans is a to b grown by depth either side and wrapped onto 0 to size, in one piece or
+// two, the whole of 0 to size if it reaches round.
+func spans(a, b, depth, size int) [][2]int {
+	if b-a+2*depth >= size {
+		return [][2]int{{0, size}}
+	}
+	lo := ((a-depth)%size + size) % size
+	hi := lo + b - a + 2*depth
+	if hi <= size {
+		return [][2]int{{lo, hi}}
+	}
+	return [][2]int{{lo, size}, {0, hi - size}}
+}
+
+// grow is the cells of r and the cells up to depth away from it, wrapped onto the world,
+// in rects that don't overlap.
+func grow(r rect, depth, width, height int) []rect {
+	var rects []rect
+	for _, x := range spans(r.x0, r.x1, depth, width) {
+		for _, y := range spans(r.y0, r.y1, depth, height) {
+			rects = append(rects, rect{x[0], x[1], y[0], y[1]})
+		}
+	}
+	return rects
+}
+
+// halo is the cells of from that the tile to needs to compute depth turns on its own:
+// the columns either side, the rows above and below and the corners, depth cells deep.
+func halo(to, from SlaveId, depth, width, height int) []rect {
+	var rects []rect
+	for _, r := range grow(tileRect(to), depth, width, height) {
+		if cells := r.intersect(tileRect(from)); !cells.empty() {
+			rects = append(rects, cells)
+		}
+	}
+	return rects
+}
+
+// neighbours is the other tiles within depth of the tile, corners too, they swap edges with it.
+func neighbours(id SlaveId, tiles []SlaveId, depth, width, height int) []SlaveId {
+	var next []SlaveId
+	for _, other := range tiles {
+		if other != id && len(halo(id, other, depth, width, height)) > 0 {
+			next = append(next, other)
+		}
+	}
+	return next
+}
+
+// haloDepth is how deep the slaves swap edges, at least 1.
+func haloDepth(p Params) int {
+	if p.HaloDepth < 1 {
+		return 1
+	}
+	return p.HaloDepth
+}
@@ -0,0 +1,137 @@
+package gol
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// What a heat map counts for each cell.
+const (
+	HeatMapFlips = "flips" // how many times the cell flipped
+	HeatMapAlive = "alive" // how many turns the cell was alive
+)
+
+// CheckHeatMap fails for a mode that isn't HeatMapFlips or HeatMapAlive, empty is no heat map.
+func CheckHeatMap(mode string) error {
+	switch mode {
+	case "", HeatMapFlips, HeatMapAlive:
+		return nil
+	}
+	return errors.New("unknown heat map " + mode + ", use flips or alive")
+}
+
+// heatMap accumulates the activity of every cell over the run. A nil heat map is no heat map,
+// it ignores the cells and has no rows.
+type heatMap struct {
+	mode   string
+	height int
+	counts []int // x-major, like the panel
+	since  []int // the turn each alive cell was born, for HeatMapAlive
+}
+
+func newHeatMap(p Params) *heatMap {
+	if p.HeatMap == "" {
+		return nil
+	}
+	h := &heatMap{
+		mode:   p.HeatMap,
+		height: p.ImageHeight,
+		counts: make([]int, p.ImageWidth*p.ImageHeight),
+	}
+	if h.mode == HeatMapAlive {
+		h.since = make([]int, len(h.counts))
+	}
+	return h
+}
+
+// load records a cell alive in the world the run starts from after turn turns, it isn't a flip.
+func (h *heatMap) load(cell util.Cell, turn int) {
+	if h != nil && h.mode == HeatMapAlive {
+		h.since[cell.X*h.height+cell.Y] = turn
+	}
+}
+
+// flip records a cell flipping at the end of turn.
+func (h *heatMap) flip(cell util.Cell, alive bool, turn int) {
+	if h == nil {
+		return
+	}
+	i := cell.X*h.height + cell.Y
+	switch h.mode {
+	case HeatMapFlips:
+		h.counts[i]++
+	case HeatMapAlive:
+		if alive {
+			h.since[i] = turn
+		} else {
+			h.counts[i] += turn - h.since[i]
+		}
+	}
+}
+
+// rows is the heat map after turn, normalised so the most active cell is 255, one row per y.
+func (h *heatMap) rows(panel [][]bool, turn int) [][]byte {
+	if h == nil {
+		return nil
+	}
+	values := make([]int, len(h.counts))
+	most := 0
+	for i, n := range h.counts {
+		if h.mode == HeatMapAlive && panel[i/h.height][i%h.height] {
+			n += turn - h.since[i]
+		}
+		values[i] = n
+		if n > most {
+			most = n
+		}
+	}
+	rows := make([][]byte, h.height)
+	for y := range rows {
+		rows[y] = make([]byte, len(panel))
+		if most == 0 {
+			continue
+		}
+		for x := range rows[y] {
+			rows[y][x] = byte(values[x*h.height+y] * 255 / most)
+		}
+	}
+	return rows
+}
+
+// heatColour maps 0-255 to black, purple, red, yellow and then white.
+func heatColour(v byte) color.RGBA {
+	stops := []color.RGBA{
+		{0, 0, 0, 255},
+		{90, 20, 130, 255},
+		{220, 40, 40, 255},
+		{250, 200, 30, 255},
+		{255, 255, 255, 255},
+	}
+	pos := float64(v) / 255 * float64(len(stops)-1)
+	i := int(pos)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	f := pos - float64(i)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*f + 0.5)
+	}
+	a, b := stops[i], stops[i+1]
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// writeHeatMapPng writes the normalised rows in false colour.
+func writeHeatMapPng(w io.Writer, rows [][]byte) error {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, v := range row {
+			img.SetRGBA(x, y, heatColour(v))
+		}
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,57 @@
+package gol
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+func TestCheckHeatMap(t *testing.T) {
+	for _, mode := range []string{"", HeatMapFlips, HeatMapAlive} {
+		if err := CheckHeatMap(mode); err != nil {
+			t.Errorf("%q: %v", mode, err)
+		}
+	}
+	for _, mode := range []string{"flip", "Alive", "x"} {
+		if err := CheckHeatMap(mode); err == nil {
+			t.Errorf("%q accepted", mode)
+		}
+	}
+}
+
+// TestHeatMapLoad checks the cells of the world the run starts from aren't flips.
+func TestHeatMapLoad(t *testing.T) {
+	panel := emptyPanel(2, 1)
+	a, b := util.Cell{X: 0, Y: 0}, util.Cell{X: 1, Y: 0}
+
+	flips := newHeatMap(Params{ImageWidth: 2, ImageHeight: 1, HeatMap: HeatMapFlips})
+	flips.load(a, 0)
+	flips.flip(b, true, 1)
+	flips.flip(b, false, 2)
+	if rows := flips.rows(panel, 2); rows[0][0] != 0 || rows[0][1] != 255 {
+		t.Errorf("flips %v, want [0 255]", rows[0])
+	}
+
+	// resumed at turn 10, a has been alive 5 turns at turn 15, b 2
+	alive := newHeatMap(Params{ImageWidth: 2, ImageHeight: 1, HeatMap: HeatMapAlive})
+	alive.load(a, 10)
+	alive.flip(b, true, 12)
+	alive.flip(b, false, 14)
+	panel[0][0] = true
+	if rows := alive.rows(panel, 15); rows[0][0] != 255 || rows[0][1] != 102 {
+		t.Errorf("alive %v, want [255 102]", rows[0])
+	}
+}
+
+// TestHeatMapOff checks no heat map is kept without a mode.
+func TestHeatMapOff(t *testing.T) {
+	h := newHeatMap(Params{ImageWidth: 5120, ImageHeight: 5120})
+	if h != nil {
+		t.Fatal("heat map without a mode")
+	}
+	h.load(util.Cell{X: 1, Y: 1}, 0)
+	h.flip(util.Cell{X: 1, Y: 1}, true, 1)
+	if rows := h.rows(emptyPanel(2, 2), 1); rows != nil {
+		t.Fatalf("%v rows without a heat map", len(rows))
+	}
+}
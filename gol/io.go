@@ -1,7 +1,11 @@
 package gol
 
 import (
+	"bufio"
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -14,14 +18,19 @@ type ioChannels struct {
 	idle    chan<- bool
 
 	filename <-chan string
-	output   <-chan uint8
-	input    chan<- uint8
+	output   <-chan []byte // one image row per send
+	input    chan<- []byte // one image row per send
+
+	checkpointOutput <-chan *Checkpoint
+	checkpointInput  chan<- *Checkpoint
+	statsOutput      <-chan TurnStats // one turn per send
 }
 
 // ioState is the internal ioState of the io goroutine.
 type ioState struct {
 	params   Params
 	channels ioChannels
+	stats    *outputFile // the stats csv being written, it gets its name at the end of the run
 }
 
 // ioCommand allows requesting behaviour from the io (pgm) goroutine.
@@ -32,94 +41,349 @@ type ioCommand uint8
 //		ioOutput 	= 0
 //		ioInput 	= 1
 //		ioCheckIdle = 2
+//		ioRemove 	= 3
+//		ioCheckpointOutput = 4
+//		ioCheckpointInput  = 5
+//		ioSeedInput = 6
+//		ioStatsOutput = 7
+//		ioHeatMapOutput = 8
+//		ioStatsRow = 9
 const (
 	ioOutput ioCommand = iota
 	ioInput
 	ioCheckIdle
+	ioRemove
+	ioCheckpointOutput
+	ioCheckpointInput
+	ioSeedInput
+	ioStatsOutput
+	ioHeatMapOutput
+	ioStatsRow
 )
 
-// writePgmImage receives an array of bytes and writes it to a pgm file.
-func (io *ioState) writePgmImage() {
+// outputName adds the extension to a filename, a .gz suffix stays last.
+func outputName(filename, ext string) string {
+	if strings.HasSuffix(filename, ".gz") {
+		return strings.TrimSuffix(filename, ".gz") + ext + ".gz"
+	}
+	return filename + ext
+}
+
+// templateName is p.OutputTemplate filled in for turn t, without an extension.
+func templateName(p Params, t int) string {
+	template := p.OutputTemplate
+	if template == "" {
+		template = "{width}x{height}x{turn}"
+	}
+	return strings.NewReplacer(
+		"{width}", strconv.Itoa(p.ImageWidth),
+		"{height}", strconv.Itoa(p.ImageHeight),
+		"{turn}", strconv.Itoa(t),
+	).Replace(template)
+}
+
+// ImageName is the name in out/ of the image after turn t, as ImageOutputComplete reports it.
+func ImageName(p Params, t int) string {
+	return outputName(templateName(p, t), ".pgm")
+}
+
+// outputFile is a file in out/ written through a temp file, it only gets its name on commit,
+// so a crash never leaves a half written file behind. Names ending in .gz are gzip compressed.
+type outputFile struct {
+	*bufio.Writer
+	file *os.File
+	gz   *gzip.Writer
+	name string
+}
+
+func createOutput(filename string) *outputFile {
 	_ = os.Mkdir("out", os.ModePerm)
+	file, ioError := ioutil.TempFile("out", filename+".*.tmp")
+	util.Check(ioError)
 
-	filename := <-io.channels.filename
-	file, ioError := os.Create("out/" + filename + ".pgm")
+	out := &outputFile{file: file, name: filename}
+	var w io.Writer = file
+	if strings.HasSuffix(filename, ".gz") {
+		out.gz = gzip.NewWriter(file)
+		w = out.gz
+	}
+	out.Writer = bufio.NewWriter(w)
+	return out
+}
+
+// commit flushes everything to disk and renames the temp file to out/name.
+func (out *outputFile) commit() {
+	ioError := out.Flush()
+	util.Check(ioError)
+	if out.gz != nil {
+		ioError = out.gz.Close()
+		util.Check(ioError)
+	}
+	ioError = out.file.Sync()
 	util.Check(ioError)
-	defer file.Close()
+	ioError = out.file.Close()
+	util.Check(ioError)
+	ioError = os.Rename(out.file.Name(), "out/"+out.name)
+	util.Check(ioError)
+}
 
-	_, _ = file.WriteString("P5\n")
-	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageWidth))
-	_, _ = file.WriteString(" ")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageHeight))
-	_, _ = file.WriteString("\n")
-	_, _ = file.WriteString(strconv.Itoa(255))
-	_, _ = file.WriteString("\n")
+// Close removes the temp file if it was never committed.
+func (out *outputFile) Close() error {
+	if out.file.Close() == nil {
+		return os.Remove(out.file.Name())
+	}
+	return nil
+}
 
-	world := make([][]byte, io.params.ImageHeight)
-	for i := range world {
-		world[i] = make([]byte, io.params.ImageWidth)
+// inputFile reads a file that may be gzip compressed.
+type inputFile struct {
+	*bufio.Reader
+	file *os.File
+}
+
+// openInput opens path, or path.gz if only that exists. Gzip data is detected
+// by its magic number and decompressed, whatever the name.
+func openInput(path string) (*inputFile, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && !strings.HasSuffix(path, ".gz") {
+		file, err = os.Open(path + ".gz")
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			val := <-io.channels.output
-			//if val != 0 {
-			//	fmt.Println(x, y)
-			//}
-			world[y][x] = val
+	in := &inputFile{Reader: bufio.NewReader(file), file: file}
+	if magic, _ := in.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(in.Reader)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
 		}
+		in.Reader = bufio.NewReader(gz)
 	}
+	return in, nil
+}
+
+func (in *inputFile) Close() error {
+	return in.file.Close()
+}
+
+// writePgmImage receives the image row by row and writes it to a pgm file.
+func (io *ioState) writePgmImage() {
+	filename := <-io.channels.filename
+	out := createOutput(outputName(filename, ".pgm"))
+	defer out.Close()
+
+	_, _ = fmt.Fprintf(out, "P5\n%v %v\n%v\n", io.params.ImageWidth, io.params.ImageHeight, 255)
+	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
 
 	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			_, ioError = file.Write([]byte{world[y][x]})
+		row := <-io.channels.output
+		_, ioError := out.Write(row)
+		util.Check(ioError)
+	}
+
+	out.commit()
+
+	fmt.Println("File", filename, "output done!")
+}
+
+// writeCheckpoint receives a checkpoint and writes it to a ckpt file.
+func (io *ioState) writeCheckpoint() {
+	filename := <-io.channels.filename
+	cp := <-io.channels.checkpointOutput
+	out := createOutput(outputName(filename, ".ckpt"))
+	defer out.Close()
+
+	ioError := WriteCheckpoint(out, cp)
+	util.Check(ioError)
+	out.commit()
+
+	fmt.Println("Checkpoint", filename, "output done!")
+}
+
+// openStats starts the stats csv with its header, if it isn't started yet.
+func (io *ioState) openStats() {
+	if io.stats != nil {
+		return
+	}
+	io.stats = createOutput("stats.csv")
+	_, ioError := fmt.Fprintln(io.stats, statsHeader)
+	util.Check(ioError)
+}
+
+// writeStatsRow receives the stats of a turn and adds them to the stats csv.
+func (io *ioState) writeStatsRow() {
+	turn := <-io.channels.statsOutput
+	io.openStats()
+	ioError := WriteStatsRow(io.stats, turn)
+	util.Check(ioError)
+}
+
+// writeStats receives the filename of the stats csv and commits it, after the last turn.
+func (io *ioState) writeStats() {
+	filename := <-io.channels.filename
+	io.openStats()
+	out := io.stats
+	io.stats = nil
+	defer out.Close()
+
+	out.name = outputName(filename, ".csv")
+	out.commit()
+
+	fmt.Println("Stats", filename, "output done!")
+}
+
+// writeHeatMap receives the normalised heat map row by row, and writes it to a pgm file,
+// or a false colour png file.
+func (io *ioState) writeHeatMap() {
+	filename := <-io.channels.filename
+	rows := make([][]byte, io.params.ImageHeight)
+	for y := range rows {
+		rows[y] = <-io.channels.output
+	}
+
+	var out *outputFile
+	if io.params.HeatMapColour {
+		out = createOutput(outputName(filename, ".png"))
+		defer out.Close()
+		ioError := writeHeatMapPng(out, rows)
+		util.Check(ioError)
+	} else {
+		out = createOutput(outputName(filename, ".pgm"))
+		defer out.Close()
+		_, _ = fmt.Fprintf(out, "P5\n%v %v\n%v\n", io.params.ImageWidth, io.params.ImageHeight, 255)
+		for _, row := range rows {
+			_, ioError := out.Write(row)
 			util.Check(ioError)
 		}
 	}
+	out.commit()
+
+	fmt.Println("Heat map", filename, "output done!")
+}
 
-	ioError = file.Sync()
+// readCheckpoint loads the checkpoint file at the given path and sends it.
+func (io *ioState) readCheckpoint() {
+	filename := <-io.channels.filename
+	cp, ioError := LoadCheckpoint(filename)
 	util.Check(ioError)
+	io.channels.checkpointInput <- cp
 
-	fmt.Println("File", filename, "output done!")
+	fmt.Println("Checkpoint", filename, "input done!")
 }
 
-// readPgmImage opens a pgm file and sends its data as an array of bytes.
+// removeOutput deletes a file written to out/, the filename includes the extension.
+func (io *ioState) removeOutput() {
+	filename := <-io.channels.filename
+	ioError := os.Remove("out/" + filename)
+	if ioError != nil && !os.IsNotExist(ioError) {
+		util.Check(ioError)
+	}
+}
+
+// readPgmHeader parses the pgm header, leaving r at the first pixel.
+// The magic is P5 for binary pixels or P2 for ascii pixels.
+func readPgmHeader(r *bufio.Reader) (magic string, width, height, maxval int, err error) {
+	var fields []int
+	for len(fields) < 3 {
+		var word string
+		if _, err = fmt.Fscan(r, &word); err != nil {
+			return
+		}
+		if strings.HasPrefix(word, "#") {
+			// skip the comment line
+			if _, err = r.ReadString('\n'); err != nil {
+				return
+			}
+			continue
+		}
+		if magic == "" {
+			magic = word
+			if magic != "P5" && magic != "P2" {
+				err = errors.New("Not a pgm file")
+				return
+			}
+			continue
+		}
+		var n int
+		if n, err = strconv.Atoi(word); err != nil {
+			return
+		}
+		fields = append(fields, n)
+	}
+	// exactly one whitespace byte before the pixels
+	_, err = r.ReadByte()
+	return magic, fields[0], fields[1], fields[2], err
+}
+
+// readPgmRow fills row from r, the receivers in this file shadow the io package.
+func readPgmRow(r *bufio.Reader, row []byte) error {
+	_, err := io.ReadFull(r, row)
+	return err
+}
+
+// readPgmImage opens a pgm file and sends its data row by row.
 func (io *ioState) readPgmImage() {
 	filename := <-io.channels.filename
-	data, ioError := ioutil.ReadFile("images/" + filename + ".pgm")
+	in, ioError := openInput("images/" + filename + ".pgm")
 	util.Check(ioError)
+	defer in.Close()
 
-	fields := strings.Fields(string(data))
+	r := in.Reader
+	magic, width, height, maxval, ioError := readPgmHeader(r)
+	util.Check(ioError)
 
-	if fields[0] != "P5" {
-		panic("Not a pgm file")
+	if magic != "P5" {
+		panic("Not a binary pgm file")
 	}
-
-	width, _ := strconv.Atoi(fields[1])
 	if width != io.params.ImageWidth {
 		panic("Incorrect width")
 	}
-
-	height, _ := strconv.Atoi(fields[2])
 	if height != io.params.ImageHeight {
 		panic("Incorrect height")
 	}
-
-	maxval, _ := strconv.Atoi(fields[3])
 	if maxval != 255 {
 		panic("Incorrect maxval/bit depth")
 	}
 
-	image := []byte(fields[4])
-
-	for _, b := range image {
-		io.channels.input <- b
+	for y := 0; y < height; y++ {
+		row := make([]byte, width)
+		ioError = readPgmRow(r, row)
+		util.Check(ioError)
+		io.channels.input <- row
 	}
 
 	fmt.Println("File", filename, "input done!")
 }
 
+// readSeedImage opens any greyscale image, converts it to cells of the board size
+// and sends them row by row like readPgmImage. An rle pattern is put in the middle of the board.
+func (io *ioState) readSeedImage() {
+	filename := <-io.channels.filename
+	in, ioError := openInput(filename)
+	util.Check(ioError)
+	defer in.Close()
+
+	var cells []byte
+	if isRLE(in.Reader) {
+		// a pattern, centred on the board
+		cells, ioError = rleCells(in.Reader, io.params.ImageWidth, io.params.ImageHeight)
+		util.Check(ioError)
+	} else {
+		img, ioError := decodeGrey(in.Reader)
+		util.Check(ioError)
+		cells, ioError = img.seedCells(io.params.ImageWidth, io.params.ImageHeight, io.params.SeedMode, io.params.SeedThreshold)
+		util.Check(ioError)
+	}
+
+	for y := 0; y < io.params.ImageHeight; y++ {
+		io.channels.input <- cells[y*io.params.ImageWidth : (y+1)*io.params.ImageWidth]
+	}
+
+	fmt.Println("Seed", filename, "input done!")
+}
+
 // startIo should be the entrypoint of the io goroutine.
 func startIo(p Params, c ioChannels) {
 	io := ioState{
@@ -137,6 +401,20 @@ func startIo(p Params, c ioChannels) {
 				io.writePgmImage()
 			case ioCheckIdle:
 				io.channels.idle <- true
+			case ioRemove:
+				io.removeOutput()
+			case ioCheckpointOutput:
+				io.writeCheckpoint()
+			case ioCheckpointInput:
+				io.readCheckpoint()
+			case ioSeedInput:
+				io.readSeedImage()
+			case ioStatsOutput:
+				io.writeStats()
+			case ioHeatMapOutput:
+				io.writeHeatMap()
+			case ioStatsRow:
+				io.writeStatsRow()
 			}
 		}
 	}
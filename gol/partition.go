@@ -0,0 +1,115 @@
+package gol
+
+import "math"
+
+// partition splits width columns into len(weights) strips in proportion to the weights.
+// Every strip gets at least one column, the columns left by rounding down go to the strips
+// with the largest remainders, so every column is given to someone.
+// There are never more strips than columns, the weights past the width get no strip.
+func partition(width int, weights []float64) []SlaveId {
+	if len(weights) > width {
+		weights = weights[:width]
+	}
+	count := len(weights)
+	var total float64
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+
+	sizes := make([]int, count)
+	remainders := make([]float64, count)
+	spare := width - count
+	left := spare
+	for i, w := range weights {
+		share := 1.0 / float64(count)
+		if total > 0 {
+			share = math.Max(w, 0) / total
+		}
+		exact := float64(spare) * share
+		sizes[i] = 1 + int(exact)
+		remainders[i] = exact - math.Floor(exact)
+		left -= int(exact)
+	}
+	for ; left > 0; left-- {
+		most := 0
+		for i := range remainders {
+			if remainders[i] > remainders[most] {
+				most = i
+			}
+		}
+		sizes[most]++
+		remainders[most] = -1
+	}
+
+	ids := make([]SlaveId, count)
+	s := 0
+	for i, size := range sizes {
+		ids[i] = SlaveId{RowStart: s, RowEnd: s + size}
+		s += size
+	}
+	return ids
+}
+
+// evenly is count equal weights.
+func evenly(count int) []float64 {
+	weights := make([]float64, count)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return weights
+}
+
+// tiles splits the world into a tile for each weight, in rows of tiles down the world
+// (1 row makes strips). The workers are shared between columns of tiles in order, the
+// columns are as wide as the weights in them, and split between their tiles by weight.
+// There are never more tiles than cells, the weights past them get no tile.
+func tiles(width, height, rows int, weights []float64) []SlaveId {
+	count := len(weights)
+	if rows > count {
+		rows = count
+	}
+	if rows > height {
+		rows = height
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if count > width*rows {
+		count = width * rows
+		weights = weights[:count]
+	}
+	columns := (count + rows - 1) / rows
+
+	// how many tiles in each column, as even as can be
+	var groups [][]float64
+	start := 0
+	for i := 0; i < columns; i++ {
+		size := count / columns
+		if i < count%columns {
+			size++
+		}
+		groups = append(groups, weights[start:start+size])
+		start += size
+	}
+	columnWeights := make([]float64, columns)
+	for i, group := range groups {
+		for _, w := range group {
+			columnWeights[i] += w
+		}
+	}
+
+	var ids []SlaveId
+	for i, column := range partition(width, columnWeights) {
+		for _, row := range partition(height, groups[i]) {
+			ids = append(ids, SlaveId{
+				RowStart: column.RowStart,
+				RowEnd:   column.RowEnd,
+				ColStart: row.RowStart,
+				ColEnd:   row.RowEnd,
+			})
+		}
+	}
+	return ids
+}
@@ -0,0 +1,79 @@
+package gol
+
+import (
+	"fmt"
+	"testing"
+)
+
+// coverOnce checks the tiles cover every cell of the world exactly once.
+func coverOnce(t *testing.T, ids []SlaveId, width, height int) {
+	t.Helper()
+	covered := make([][]int, width)
+	for x := range covered {
+		covered[x] = make([]int, height)
+	}
+	for _, id := range ids {
+		if id.RowStart >= id.RowEnd || id.ColStart >= id.ColEnd {
+			t.Fatalf("empty tile %v", id)
+		}
+		for x := id.RowStart; x < id.RowEnd; x++ {
+			for y := id.ColStart; y < id.ColEnd; y++ {
+				covered[x][y]++
+			}
+		}
+	}
+	for x := range covered {
+		for y, n := range covered[x] {
+			if n != 1 {
+				t.Fatalf("cell (%v, %v) covered %v times by %v", x, y, n, ids)
+			}
+		}
+	}
+}
+
+// TestPartition splits the columns between 1, width and more than width strips.
+func TestPartition(t *testing.T) {
+	for _, width := range []int{1, 7, 16, 512} {
+		for _, count := range []int{1, 3, width, width + 1, 2*width + 5} {
+			for _, uneven := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%vcolumns_%vstrips_uneven%v", width, count, uneven), func(t *testing.T) {
+					weights := evenly(count)
+					if uneven {
+						for i := range weights {
+							weights[i] = float64(i%4) + 0.5
+						}
+					}
+					ids := partition(width, weights)
+					want := count
+					if want > width {
+						want = width
+					}
+					if len(ids) != want {
+						t.Fatalf("%v strips, want %v", len(ids), want)
+					}
+					for i := range ids {
+						ids[i].ColEnd = 1
+					}
+					coverOnce(t, ids, width, 1)
+				})
+			}
+		}
+	}
+}
+
+// TestTiles splits a world into rows of tiles, for more tiles than fit too.
+func TestTiles(t *testing.T) {
+	for _, size := range [][2]int{{1, 1}, {5, 3}, {16, 16}, {64, 32}} {
+		for _, rows := range []int{0, 1, 2, 3, 100} {
+			for _, count := range []int{1, 2, 5, size[0], size[0]*3 + 1, size[0]*size[1] + 2} {
+				t.Run(fmt.Sprintf("%vx%v_%vrows_%vtiles", size[0], size[1], rows, count), func(t *testing.T) {
+					ids := tiles(size[0], size[1], rows, evenly(count))
+					if len(ids) > count {
+						t.Fatalf("%v tiles for %v weights", len(ids), count)
+					}
+					coverOnce(t, ids, size[0], size[1])
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package gol
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// isRLE is true when the input starts like a run length encoded pattern, a # comment or
+// the x = header. Images start with P, 0x89, 0xff or G.
+func isRLE(r *bufio.Reader) bool {
+	magic, _ := r.Peek(1)
+	return len(magic) == 1 && (magic[0] == '#' || magic[0] == 'x')
+}
+
+// decodeRLE reads a pattern in the run length encoded format of Golly and LifeWiki:
+// # comment lines, a header like "x = 3, y = 3, rule = B3/S23", then runs of
+// b (dead) and o (alive) cells, $ ending a row and ! ending the pattern.
+// Any other letter is a state of a multistate rule and counts as alive.
+func decodeRLE(r *bufio.Reader) (width, height int, alive []util.Cell, err error) {
+	// the header, after the comments
+	var header string
+	for header == "" {
+		line, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return 0, 0, nil, errors.New("rle pattern without a header")
+		}
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			header = line
+		}
+	}
+	width, height = -1, -1
+	for _, field := range strings.Split(header, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return 0, 0, nil, fmt.Errorf("rle header %q", header)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "x":
+			width, err = strconv.Atoi(value)
+		case "y":
+			height, err = strconv.Atoi(value)
+		case "rule":
+			if rule := strings.ToUpper(value); rule != "B3/S23" && rule != "23/3" {
+				return 0, 0, nil, fmt.Errorf("rle pattern for rule %v, only B3/S23 runs here", value)
+			}
+		}
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("rle header %q: %v", header, err)
+		}
+	}
+	if width < 0 || height < 0 {
+		return 0, 0, nil, fmt.Errorf("rle header %q without x and y", header)
+	}
+
+	// the runs
+	x, y, count := 0, 0, 0
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			// some files leave out the !
+			return width, height, alive, nil
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		n := count
+		if n == 0 {
+			n = 1
+		}
+		switch {
+		case c >= '0' && c <= '9':
+			count = count*10 + int(c-'0')
+			continue
+		case c == '!':
+			return width, height, alive, nil
+		case c == '$':
+			y += n
+			x = 0
+		case c == 'b' || c == '.':
+			x += n
+		case unicode.IsLetter(rune(c)):
+			for i := 0; i < n; i++ {
+				if x >= width || y >= height {
+					return 0, 0, nil, fmt.Errorf("rle cell %v,%v outside the %vx%v pattern", x, y, width, height)
+				}
+				alive = append(alive, util.Cell{X: x, Y: y})
+				x++
+			}
+		case unicode.IsSpace(rune(c)):
+		default:
+			return 0, 0, nil, fmt.Errorf("unexpected %q in rle pattern", c)
+		}
+		count = 0
+	}
+}
+
+// rleCells reads a pattern and centres it on a width x height board, the cells come
+// row by row like the pixels of a pgm.
+func rleCells(r *bufio.Reader, width, height int) ([]byte, error) {
+	w, h, alive, err := decodeRLE(r)
+	if err != nil {
+		return nil, err
+	}
+	if w > width || h > height {
+		return nil, fmt.Errorf("rle pattern of %vx%v doesn't fit the %vx%v board", w, h, width, height)
+	}
+	cells := make([]byte, width*height)
+	x0, y0 := (width-w)/2, (height-h)/2
+	for _, cell := range alive {
+		cells[(y0+cell.Y)*width+x0+cell.X] = 255
+	}
+	return cells, nil
+}
@@ -0,0 +1,121 @@
+package gol
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gliderRLE = `#N Glider
+#C the smallest spaceship
+x = 3, y = 3, rule = B3/S23
+bob$2bo$3o!
+`
+
+func TestDecodeRLE(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader(gliderRLE))
+	if !isRLE(r) {
+		t.Fatal("glider not taken for rle")
+	}
+	width, height, alive, err := decodeRLE(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 3 || height != 3 {
+		t.Fatalf("glider is %vx%v", width, height)
+	}
+	want := "(1,0) (2,1) (0,2) (1,2) (2,2)"
+	var got []string
+	for _, cell := range alive {
+		got = append(got, fmt.Sprintf("(%v,%v)", cell.X, cell.Y))
+	}
+	if strings.Join(got, " ") != want {
+		t.Fatalf("glider cells %v, want %v", got, want)
+	}
+
+	// runs across lines, empty rows and a missing !
+	_, _, alive, err = decodeRLE(bufio.NewReader(strings.NewReader("x = 4, y = 4\n2o\n2b$\n2$o")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alive) != 3 || alive[2].X != 0 || alive[2].Y != 3 {
+		t.Fatalf("cells %v", alive)
+	}
+}
+
+func TestDecodeRLEErrors(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"#C only a comment\n",
+		"y = 3\nooo!",
+		"x = 3, y = 3, rule = B36/S23\nooo!",
+		"x = 2, y = 1\nooo!",
+		"x = 3, y = 1\no?o!",
+	} {
+		if _, _, _, err := decodeRLE(bufio.NewReader(strings.NewReader(data))); err == nil {
+			t.Errorf("%q decoded without an error", data)
+		}
+	}
+	if isRLE(bufio.NewReader(strings.NewReader("P5\n1 1\n255\n\x00"))) {
+		t.Error("pgm taken for rle")
+	}
+}
+
+// TestRLESeed loads a gzip compressed pattern by its name without .gz, into the middle of the board.
+func TestRLESeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file, err := os.Create(filepath.Join(dir, "glider.rle.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	_, _ = gz.Write([]byte(gliderRLE))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	in, err := openInput(filepath.Join(dir, "glider.rle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	if !isRLE(in.Reader) {
+		t.Fatal("gzip compressed glider not taken for rle")
+	}
+	cells, err := rleCells(in.Reader, 8, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for y := 0; y < 6; y++ {
+		row := ""
+		for x := 0; x < 8; x++ {
+			if cells[y*8+x] == 255 {
+				row += "o"
+			} else {
+				row += "."
+			}
+		}
+		rows = append(rows, row)
+	}
+	want := "........\n...o....\n....o...\n..ooo...\n........\n........"
+	if strings.Join(rows, "\n") != want {
+		t.Fatalf("board\n%v\nwant\n%v", strings.Join(rows, "\n"), want)
+	}
+
+	if _, err := rleCells(bufio.NewReader(strings.NewReader(gliderRLE)), 2, 2); err == nil {
+		t.Error("glider fitted on a 2x2 board")
+	}
+}
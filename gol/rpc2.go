@@ -4,182 +4,932 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/rpc"
+	"sort"
 	"sync"
+	"time"
+
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
-type Point struct {
-	cell  util.Cell
-	value bool
-}
+// A slave not heard from for SlaveTimeout is lost, slaves send a heartbeat every HeartbeatInterval.
+const (
+	HeartbeatInterval = time.Second
+	SlaveTimeout      = 5 * time.Second
+)
 
-// eg. check from [0, 10)
+// CheckNextTurn returns when the slave has something to do, or after BarrierTimeout.
+const BarrierTimeout = 10 * time.Second
+
+// eg. check from [0, 10), the x of the cells are from RowStart to RowEnd, the y from ColStart to ColEnd
 type SlaveId struct {
 	RowStart int // contain
 	RowEnd   int // not contain
+	ColStart int // contain
+	ColEnd   int // not contain
+}
+
+func (id SlaveId) cells() int {
+	return (id.RowEnd - id.RowStart) * (id.ColEnd - id.ColStart)
 }
 
-type SlaveConfigParam struct{}
+type SlaveConfigParam struct {
+	Address  string // where the other slaves push their edges to this slave, it names the slave
+	Capacity int    // eg. the number of cores, until the slave has been timed
+}
 
 type SlaveConfigResponse struct {
 	Id     SlaveId
 	Params Params
+	Turn   int   // the turn the strip is at
+	Epoch  int   // the strips change every epoch
+	State  Strip // the strip
+	Wait   bool  // no strip for this slave yet, ask again later
+	Exit   bool
 }
 
 type CheckNextTurnParam struct {
-	Id SlaveId
+	Id           SlaveId
+	Turn         int // turns this slave has completed
+	Epoch        int
+	TurnTime     time.Duration // how long the last turn took to compute, 0 once told
+	StateRequest int           // the last state request reported
+
+	Sent     bool        // Flipped and Checksum are for Turn, sent once
+	Flipped  []util.Cell // the cells of the strip flipped in Turn
+	Checksum uint64      // of the strip after Turn, every ChecksumTurns turns, 0 otherwise
+
+	Unreachable string // the address of a neighbour the edges couldn't be pushed to
 }
 
 type CheckNextTurnResponse struct {
-	AllReady   bool // all slave report there
-	MissSlaves []SlaveId
-	Exit       bool
-}
+	AllReady    bool // all slave report there, go on with the next turn
+	MissSlaves  []SlaveId
+	Exit        bool
+	Reconfigure bool // the strips changed, fetch the config again
 
-type NextTurnParam struct {
-	Id SlaveId
-}
+	WantState    bool // report the strip with ReportMyState before going on
+	StateRequest int  // each request is reported once
 
-type NextTurnResponse struct {
-	Turn  int
-	Edges []Point
+	Neighbours map[SlaveId]string // addresses of the tiles next to the slave's, to swap edges with
 }
 
 type ReportParam struct {
 	Id      SlaveId
 	Turn    int
-	MyState []Point
+	Epoch   int
+	MyState Strip
 }
 type ReportResponse struct {
 }
 
+type LeaveParam struct {
+	Address string
+}
+type LeaveResponse struct {
+}
+
+type ParamsParam struct {
+}
+type ParamsResponse struct {
+	Params Params
+}
+
+type HeartbeatParam struct {
+	Address string
+}
+type HeartbeatResponse struct {
+}
+
+// EdgesParam is the cells of a tile another tile needs, pushed to its slave before computing Turn+1.
+type EdgesParam struct {
+	Epoch int
+	Turn  int
+	From  SlaveId
+	Edges []Strip
+}
+type EdgesResponse struct {
+}
+
 type GolMasterAPI interface {
 	FetchMyConfig(param *SlaveConfigParam, response *SlaveConfigResponse) error
 	CheckNextTurn(param *CheckNextTurnParam, response *CheckNextTurnResponse) error
-	FetchNextTurn(param *NextTurnParam, response *NextTurnResponse) error
 	ReportMyState(param *ReportParam, response *ReportResponse) error
+	Heartbeat(param *HeartbeatParam, response *HeartbeatResponse) error
+	Leave(param *LeaveParam, response *LeaveResponse) error
+}
+
+type GolSlaveAPI interface {
+	PushEdges(param *EdgesParam, response *EdgesResponse) error
 }
 
 type MasterHandle struct {
-	OnSlaveFinish  func(points []Point) // set slave's points
+	OnSlaveFinish  func(strip Strip) // set slave's strip
+	OnFlipped      func(turn int, cells []util.Cell)
 	OnTurnComplete func(turn int)
-	GetByIndex     func(cell util.Cell) Point // read by index
 	CheckExit      func() bool
+	CheckPause     func() bool
+	GetStrip       func(id SlaveId) Strip // the strip of the world at the completed turn
 }
 
+// GolMasterServer only keeps the slaves in step, the slaves swap their edges with
+// each other. The slaves send the cells they flipped each turn, which the master adds
+// to its world when the turn is complete, and a checksum of their strip every
+// ChecksumTurns turns. When a checksum is wrong the slaves send their whole strips.
+// Slaves join and leave at any turn: the master splits the world again between the
+// slaves when the next turn is complete. A slave is lost when its heartbeats stop, or when
+// a neighbour can't push its edges to it and the master can't reach it either. Then the world
+// is split between the rest, and every slave goes back to the completed turn.
 type GolMasterServer struct {
 	params     Params
 	slaveCount int
 	handle     *MasterHandle
 
 	slaveTurnLock sync.Mutex
-	slaveTurnMap  map[SlaveId]int // register each salve turn
+	changed       *sync.Cond           // on slaveTurnLock, whenever the barrier may have opened
+	slaveTurnMap  map[SlaveId]int      // register each salve turn
+	slaveAddress  map[SlaveId]string   // the slave of each strip, empty if none
+	lastSeen      map[string]time.Time // by address
+	spares        map[string]bool      // slaves waiting for a strip
+	leaving       map[string]bool      // slaves that asked to leave
+	repartition   bool                 // split the world again once it is taken
+	capacity      map[string]int       // by address
+	speed         map[string]float64   // cells computed a second, by address
+	exited        map[SlaveId]bool     // slaves told to exit
 	thisTurn      int
+	epoch         int
+	opened        bool // a slave has started the turn after thisTurn
 
-	reportStateMap map[SlaveId][]Point
+	stateWaiting   []chan int // waiting for the world to be whole again
+	stateTurn      int        // the turn being reported, NotTake if none
+	stateRequest   int
+	resync         bool // ask for the whole strips, a checksum was wrong
+	flipped        map[SlaveId]delta
+	arrived        map[string]arrival
+	waits          map[string]*BarrierWait
+	reportStateMap map[SlaveId]Strip
 }
 
 const (
-	NotTake = -1
-	Init    = 0
+	NotTake  = -1
+	NotReady = -2 // the slave hasn't fetched its strip since the epoch changed
+	Init     = 0
 )
 
 func NewGolMasterServer(params Params, slaveCount int, handle *MasterHandle) *GolMasterServer {
 	// init slaveTurnMap
 	var slaveTurnMap = make(map[SlaveId]int)
-	c := params.ImageWidth / slaveCount
-	for i := 0; i < slaveCount; i++ {
-		s := i * c
-		e := s + c
-		if e > params.ImageWidth {
-			e = params.ImageWidth
-		}
-		salveId := SlaveId{RowStart: s, RowEnd: e}
+	ids := tiles(params.ImageWidth, params.ImageHeight, params.TileRows, evenly(slaveCount))
+	for _, salveId := range ids {
 		slaveTurnMap[salveId] = NotTake
 	}
+	if len(ids) < slaveCount {
+		// more slaves than columns, the rest join as spares
+		fmt.Println("the world only splits into", len(ids), "strips, starting with", len(ids), "slaves")
+		slaveCount = len(ids)
+	}
 	fmt.Printf("master with %#v, slave count %#v, init slaveTurnMap is %#v \n", params, slaveCount, slaveTurnMap)
 
-	return &GolMasterServer{
+	g := &GolMasterServer{
 		params:         params,
 		slaveCount:     slaveCount,
 		handle:         handle,
 		slaveTurnMap:   slaveTurnMap,
+		slaveAddress:   make(map[SlaveId]string, slaveCount),
+		lastSeen:       make(map[string]time.Time),
+		spares:         make(map[string]bool),
+		leaving:        make(map[string]bool),
+		capacity:       make(map[string]int),
+		speed:          make(map[string]float64),
+		arrived:        make(map[string]arrival),
+		flipped:        make(map[SlaveId]delta, slaveCount),
+		waits:          make(map[string]*BarrierWait),
+		exited:         make(map[SlaveId]bool, slaveCount),
 		thisTurn:       Init,
-		reportStateMap: make(map[SlaveId][]Point, slaveCount),
+		stateTurn:      NotTake,
+		reportStateMap: make(map[SlaveId]Strip, slaveCount),
 	}
+	g.changed = sync.NewCond(&g.slaveTurnLock)
+	go g.watch()
+	return g
 }
 
 func (g *GolMasterServer) setHandle(handle *MasterHandle) {
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
 	g.handle = handle
 }
 
+// setTurn starts counting from turn, when resuming from a checkpoint.
+func (g *GolMasterServer) setTurn(turn int) {
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+	g.thisTurn = turn
+}
+
+// waitTurn waits until the completed turn isn't turn, or about timeout, it returns the completed turn.
+func (g *GolMasterServer) waitTurn(turn int, timeout time.Duration) int {
+	// the wait doesn't end at the deadline without a broadcast
+	timer := time.AfterFunc(timeout, g.wake)
+	defer timer.Stop()
+	deadline := time.Now().Add(timeout)
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+	for g.thisTurn == turn && time.Now().Before(deadline) {
+		g.changed.Wait()
+	}
+	return g.thisTurn
+}
+
+// wake lets the slaves waiting at the barrier see a pause or an exit, or their deadline.
+func (g *GolMasterServer) wake() {
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+	g.changed.Broadcast()
+}
+
+// requestState waits while the slaves are sending their whole strips after a wrong checksum,
+// then the world the master has is the completed turn. It fails if they haven't after timeout,
+// eg. a slave was lost or none is left.
+func (g *GolMasterServer) requestState(timeout time.Duration) error {
+	g.slaveTurnLock.Lock()
+	if !g.resync {
+		g.slaveTurnLock.Unlock()
+		return nil
+	}
+	// sent to once, it doesn't block if nobody waits any more
+	done := make(chan int, 1)
+	g.stateWaiting = append(g.stateWaiting, done)
+	g.slaveTurnLock.Unlock()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("the slaves didn't resend their strips in time")
+	}
+}
+
+// waitExited gives the slaves up to timeout to see the exit.
+func (g *GolMasterServer) waitExited(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		g.slaveTurnLock.Lock()
+		n := len(g.exited)
+		g.slaveTurnLock.Unlock()
+		if n == len(g.slaveTurnMap) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// allAt is true when every slave has completed turn.
+func (g *GolMasterServer) allAt(turn int) bool {
+	for _, t := range g.slaveTurnMap {
+		if t != turn {
+			return false
+		}
+	}
+	return true
+}
+
+// tiles is every tile of the world.
+func (g *GolMasterServer) tiles() []SlaveId {
+	var ids []SlaveId
+	for slaveId := range g.slaveTurnMap {
+		ids = append(ids, slaveId)
+	}
+	return ids
+}
+
+// strip is the strip of the slave at address.
+func (g *GolMasterServer) strip(address string) (SlaveId, bool) {
+	for slaveId, a := range g.slaveAddress {
+		if a == address {
+			return slaveId, true
+		}
+	}
+	return SlaveId{}, false
+}
+
+// watch looks for slaves that stopped sending heartbeats.
+func (g *GolMasterServer) watch() {
+	for range time.Tick(HeartbeatInterval) {
+		g.slaveTurnLock.Lock()
+		if g.handle != nil && !g.handle.CheckExit() {
+			for address, seen := range g.lastSeen {
+				if time.Since(seen) >= SlaveTimeout {
+					g.lose(address)
+				}
+			}
+		}
+		// the waiting slaves look at the exit and the pause again
+		g.changed.Broadcast()
+		g.slaveTurnLock.Unlock()
+	}
+}
+
+// lose forgets the slave at address, its strip goes to the others.
+func (g *GolMasterServer) lose(address string) {
+	delete(g.lastSeen, address)
+	delete(g.spares, address)
+	delete(g.leaving, address)
+	if slaveId, ok := g.strip(address); ok {
+		fmt.Println("slave", address, "with", slaveId, "lost")
+		g.slaveAddress[slaveId] = ""
+		g.layout()
+	}
+}
+
+// reachable is true when a slave takes connections at address.
+func reachable(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, HeartbeatInterval)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// Rebalance when the slowest strip takes RebalanceTolerance longer than the fastest.
+const RebalanceTolerance = 0.25
+
+// weights is how many columns each slave should get: its speed, or for the slaves not
+// timed yet their capacity times the speed of a unit of capacity of the others.
+// The slaves with a strip only move half way from it, as how fast a slave seems
+// changes with the strips when the slaves share a machine.
+func (g *GolMasterServer) weights(workers []string) []float64 {
+	var speed, capacity float64
+	for _, address := range workers {
+		if g.speed[address] > 0 {
+			speed += g.speed[address]
+			capacity += float64(g.capacityOf(address))
+		}
+	}
+	weights := make([]float64, len(workers))
+	for i, address := range workers {
+		switch {
+		case g.speed[address] > 0:
+			weights[i] = g.speed[address]
+		case speed > 0:
+			weights[i] = float64(g.capacityOf(address)) * speed / capacity
+		default:
+			weights[i] = float64(g.capacityOf(address))
+		}
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	for i, address := range workers {
+		weights[i] /= total
+		if slaveId, ok := g.strip(address); ok {
+			weights[i] = (weights[i] + float64(slaveId.cells())/float64(g.params.ImageWidth*g.params.ImageHeight)) / 2
+		}
+	}
+	return weights
+}
+
+func (g *GolMasterServer) capacityOf(address string) int {
+	if g.capacity[address] < 1 {
+		return 1
+	}
+	return g.capacity[address]
+}
+
+// time takes the time a slave took for a turn of its strip into its speed.
+func (g *GolMasterServer) time(slaveId SlaveId, turnTime time.Duration) {
+	if turnTime <= 0 {
+		return
+	}
+	address := g.slaveAddress[slaveId]
+	speed := float64(slaveId.cells()) / turnTime.Seconds()
+	if g.speed[address] == 0 {
+		g.speed[address] = speed
+	} else {
+		// a moving average, so a slave that is slow once isn't given less straight away
+		g.speed[address] = 0.9*g.speed[address] + 0.1*speed
+	}
+}
+
+// unbalanced is true when a strip is expected to take much longer than another.
+func (g *GolMasterServer) unbalanced() bool {
+	fastest, slowest := math.Inf(1), 0.0
+	for slaveId, address := range g.slaveAddress {
+		if address == "" || g.speed[address] == 0 {
+			return false
+		}
+		t := float64(slaveId.cells()) / g.speed[address]
+		fastest = math.Min(fastest, t)
+		slowest = math.Max(slowest, t)
+	}
+	// too quick to be worth moving the strips
+	return slowest > 0.001 && slowest > fastest*(1+RebalanceTolerance)
+}
+
+// layout splits the world between the slaves, those with a strip first in the order of
+// their strips, then the new ones, by their speed, and starts a new epoch from the last world the master has.
+func (g *GolMasterServer) layout() {
+	var ids []SlaveId
+	for id, address := range g.slaveAddress {
+		if address != "" && !g.leaving[address] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].RowStart != ids[j].RowStart {
+			return ids[i].RowStart < ids[j].RowStart
+		}
+		return ids[i].ColStart < ids[j].ColStart
+	})
+	var workers []string
+	for _, id := range ids {
+		workers = append(workers, g.slaveAddress[id])
+	}
+	var joined []string
+	for address := range g.spares {
+		joined = append(joined, address)
+	}
+	sort.Strings(joined)
+	workers = append(workers, joined...)
+
+	weights := evenly(1)
+	if len(workers) > 0 {
+		weights = g.weights(workers)
+	} else {
+		fmt.Println("no slave left, waiting for one to join")
+	}
+	// there may be fewer tiles than slaves in a small world
+	tiled := tiles(g.params.ImageWidth, g.params.ImageHeight, g.params.TileRows, weights)
+	count := len(tiled)
+	g.spares = make(map[string]bool)
+	g.slaveTurnMap = make(map[SlaveId]int, count)
+	g.slaveAddress = make(map[SlaveId]string, count)
+	for i, id := range tiled {
+		g.slaveTurnMap[id] = NotTake
+		if i < len(workers) {
+			g.slaveAddress[id] = workers[i]
+			g.slaveTurnMap[id] = NotReady
+			fmt.Println("slave", workers[i], "takes", id)
+		}
+	}
+	if len(workers) > count {
+		// more slaves than tiles
+		for _, address := range workers[count:] {
+			g.spares[address] = true
+		}
+	}
+
+	// every slave starts again from the completed turn
+	g.epoch++
+	g.opened = false
+	g.repartition = false
+	g.stateTurn = NotTake
+	g.reportStateMap = make(map[SlaveId]Strip, count)
+	g.flipped = make(map[SlaveId]delta, count)
+	g.exited = make(map[SlaveId]bool, count)
+	g.changed.Broadcast()
+}
+
 func (g *GolMasterServer) FetchMyConfig(param *SlaveConfigParam, response *SlaveConfigResponse) error {
 	g.slaveTurnLock.Lock()
 	defer g.slaveTurnLock.Unlock()
+	if g.handle == nil {
+		response.Wait = true
+		return nil
+	}
+	if g.handle.CheckExit() || g.leaving[param.Address] {
+		response.Exit = true
+		delete(g.leaving, param.Address)
+		delete(g.lastSeen, param.Address)
+		return nil
+	}
+	g.lastSeen[param.Address] = time.Now()
+	g.capacity[param.Address] = param.Capacity
 
-	for slaveId, t := range g.slaveTurnMap {
+	slaveId, ok := g.strip(param.Address)
+	if !ok {
+		// a new slave takes a strip without one
+		for id, t := range g.slaveTurnMap {
+			if t == NotTake {
+				slaveId, ok = id, true
+				g.slaveAddress[id] = param.Address
+				delete(g.spares, param.Address)
+				fmt.Println("slave", param.Address, "takes", slaveId)
+				if g.allTaken() && !g.evenCapacity() {
+					// the strips were split evenly before the slaves came
+					g.repartition = true
+				}
+				break
+			}
+		}
+	}
+	if !ok {
+		if !g.spares[param.Address] {
+			// split the world again at the next turn
+			fmt.Println("slave", param.Address, "joins")
+			g.spares[param.Address] = true
+			g.repartition = true
+		}
+		response.Wait = true
+		return nil
+	}
+
+	response.Params = g.params
+	response.Id = slaveId
+	response.Turn = g.thisTurn
+	response.Epoch = g.epoch
+	response.State = g.handle.GetStrip(slaveId)
+	g.slaveTurnMap[slaveId] = g.thisTurn
+	g.changed.Broadcast()
+	return nil
+}
+
+// FetchParams gives the params of the run, with the board the master started from.
+func (g *GolMasterServer) FetchParams(param *ParamsParam, response *ParamsResponse) error {
+	response.Params = g.params
+	return nil
+}
+
+func (g *GolMasterServer) Heartbeat(param *HeartbeatParam, response *HeartbeatResponse) error {
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+	if _, ok := g.lastSeen[param.Address]; ok {
+		g.lastSeen[param.Address] = time.Now()
+	}
+	return nil
+}
+
+// allTaken is true when every strip has a slave.
+func (g *GolMasterServer) allTaken() bool {
+	for _, t := range g.slaveTurnMap {
 		if t == NotTake {
-			response.Params = g.params
-			response.Id = slaveId
-			g.slaveTurnMap[slaveId] = Init
-			return nil
+			return false
+		}
+	}
+	return true
+}
+
+// evenCapacity is true when the slaves all have the same capacity.
+func (g *GolMasterServer) evenCapacity() bool {
+	capacity := 0
+	for _, address := range g.slaveAddress {
+		if capacity != 0 && g.capacityOf(address) != capacity {
+			return false
 		}
+		capacity = g.capacityOf(address)
+	}
+	return true
+}
+
+// Leave lets the slave go once the world is split between the others.
+func (g *GolMasterServer) Leave(param *LeaveParam, response *LeaveResponse) error {
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+	fmt.Println("slave", param.Address, "leaves")
+	g.leaving[param.Address] = true
+	if g.spares[param.Address] {
+		delete(g.spares, param.Address)
+	} else {
+		g.repartition = true
+		g.changed.Broadcast()
 	}
 	return nil
 }
 
 func (g *GolMasterServer) CheckNextTurn(param *CheckNextTurnParam, response *CheckNextTurnResponse) error {
-	response.AllReady = true
-	for slaveId, t := range g.slaveTurnMap {
-		if t != g.thisTurn {
-			response.AllReady = false
-			response.MissSlaves = append(response.MissSlaves, slaveId)
+	// a neighbour the slave couldn't push to is lost if the master can't reach it either,
+	// the world is split again straight away instead of at the heartbeat timeout
+	lost := param.Unreachable != "" && !reachable(param.Unreachable)
+
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+
+	if lost && !g.stale(param) && !g.handle.CheckExit() {
+		fmt.Println("slave", param.Unreachable, "unreachable from", g.slaveAddress[param.Id])
+		g.lose(param.Unreachable)
+	}
+	if g.stale(param) {
+		response.Exit = g.handle.CheckExit()
+		response.Reconfigure = !response.Exit
+		return nil
+	}
+	g.lastSeen[g.slaveAddress[param.Id]] = time.Now()
+	g.slaveTurnMap[param.Id] = param.Turn
+	g.time(param.Id, param.TurnTime)
+	if param.Turn == g.thisTurn+1 && param.Sent {
+		if inside(tileRect(param.Id), param.Flipped) {
+			g.flipped[param.Id] = delta{turn: param.Turn, cells: param.Flipped, checksum: param.Checksum}
+		} else {
+			// left out, so the turn resyncs the whole strips
+			fmt.Println("flipped cells outside the strip of", param.Id)
 		}
 	}
-	response.Exit = g.handle.CheckExit()
+
+	// every slave finished the turn
+	if g.allAt(g.thisTurn + 1) {
+		g.complete()
+	}
+	g.arrive(param)
+	g.changed.Broadcast()
+
+	// wait at the barrier until there is something for the slave to do. The barrier is open
+	// for a slave that couldn't push its edges, so it waits up to HeartbeatInterval for the
+	// strips to change before trying again
+	timeout := BarrierTimeout
+	if param.Unreachable != "" {
+		timeout = HeartbeatInterval
+	}
+	timer := time.AfterFunc(timeout, g.wake)
+	defer timer.Stop()
+	deadline := time.Now().Add(timeout)
+	for {
+		ok := g.answer(param, response)
+		if ok && (param.Unreachable == "" || response.Exit) || !time.Now().Before(deadline) {
+			break
+		}
+		g.changed.Wait()
+		if g.stale(param) {
+			*response = CheckNextTurnResponse{Exit: g.handle.CheckExit()}
+			response.Reconfigure = !response.Exit
+			return nil
+		}
+	}
+	if response.AllReady {
+		g.passed(param)
+	}
 	return nil
 }
 
-func (g *GolMasterServer) FetchNextTurn(param *NextTurnParam, response *NextTurnResponse) error {
-	// send slave's edges
-	if left := param.Id.RowStart - 1; left > 0 {
-		for j := 0; j < g.params.ImageHeight; j++ {
-			point := g.handle.GetByIndex(util.Cell{X: left, Y: j})
-			response.Edges = append(response.Edges, point)
+// inside is true when all the cells are in r.
+func inside(r rect, cells []util.Cell) bool {
+	for _, cell := range cells {
+		if !r.contains(cell.X, cell.Y) {
+			return false
 		}
 	}
+	return true
+}
+
+// delta is the cells of a strip flipped in a turn.
+type delta struct {
+	turn     int
+	cells    []util.Cell
+	checksum uint64 // of the strip after the turn, 0 if not sent
+}
 
-	if right := param.Id.RowEnd; right < g.params.ImageWidth {
-		for j := 0; j < g.params.ImageHeight; j++ {
-			point := g.handle.GetByIndex(util.Cell{X: right, Y: j})
-			response.Edges = append(response.Edges, point)
+// complete adds the cells flipped in the turn every slave has finished to the world.
+func (g *GolMasterServer) complete() {
+	g.thisTurn += 1
+	g.opened = false
+	for slaveId := range g.slaveTurnMap {
+		d, ok := g.flipped[slaveId]
+		if !ok || d.turn != g.thisTurn {
+			// a slave that sent its strip at the turn before, or lost its flips
+			fmt.Println("no flipped cells from", slaveId, "at turn", g.thisTurn, "resyncing")
+			g.resync = true
+			continue
 		}
+		g.handle.OnFlipped(g.thisTurn, d.cells)
+	}
+	for slaveId := range g.slaveTurnMap {
+		d := g.flipped[slaveId]
+		if d.checksum != 0 && d.turn == g.thisTurn && d.checksum != g.handle.GetStrip(slaveId).checksum() {
+			fmt.Println("wrong checksum from", slaveId, "at turn", g.thisTurn, "resyncing")
+			g.resync = true
+		}
+	}
+	g.flipped = make(map[SlaveId]delta, len(g.slaveTurnMap))
+	g.handle.OnTurnComplete(g.thisTurn)
+
+	if g.params.RebalanceTurns > 0 && g.thisTurn%g.params.RebalanceTurns == 0 && !g.repartition && g.unbalanced() {
+		fmt.Println("rebalancing the strips")
+		g.repartition = true
+	}
+	if g.repartition && !g.resync {
+		// no slave has gone past the turn, the strips change now
+		g.layout()
 	}
-	response.Turn = g.thisTurn
-	return nil
 }
 
-func (g *GolMasterServer) ReportMyState(param *ReportParam, response *ReportResponse) error {
-	if param.Turn != g.thisTurn {
-		return errors.New("invalid turn")
+// stale is true when the strips changed since the slave fetched its config.
+func (g *GolMasterServer) stale(param *CheckNextTurnParam) bool {
+	_, ok := g.slaveTurnMap[param.Id]
+	return !ok || param.Epoch != g.epoch
+}
+
+// arrival is when a slave got to a barrier.
+type arrival struct {
+	turn, epoch int
+	at          time.Time
+}
+
+// BarrierWait is how long a slave has waited at the barriers for the others.
+type BarrierWait struct {
+	Address  string
+	Barriers int
+	Total    time.Duration
+	Max      time.Duration
+}
+
+func (w BarrierWait) String() string {
+	return fmt.Sprintf("%v: %v barriers, waited %v, %v on average, %v at most",
+		w.Address, w.Barriers, w.Total, w.Total/time.Duration(w.Barriers), w.Max)
+}
+
+func (g *GolMasterServer) arrive(param *CheckNextTurnParam) {
+	address := g.slaveAddress[param.Id]
+	if a, ok := g.arrived[address]; !ok || a.turn != param.Turn || a.epoch != param.Epoch {
+		g.arrived[address] = arrival{turn: param.Turn, epoch: param.Epoch, at: time.Now()}
+	}
+}
+
+// passed counts the wait of a slave the barrier has opened for.
+func (g *GolMasterServer) passed(param *CheckNextTurnParam) {
+	address := g.slaveAddress[param.Id]
+	a, ok := g.arrived[address]
+	if !ok {
+		return
+	}
+	delete(g.arrived, address)
+	wait := time.Since(a.at)
+	w := g.waits[address]
+	if w == nil {
+		w = &BarrierWait{Address: address}
+		g.waits[address] = w
+	}
+	w.Barriers++
+	w.Total += wait
+	if wait > w.Max {
+		w.Max = wait
+	}
+}
+
+// barrierWaits is how long each slave has waited at the barriers so far.
+func (g *GolMasterServer) barrierWaits() []BarrierWait {
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+	var waits []BarrierWait
+	for _, w := range g.waits {
+		waits = append(waits, *w)
 	}
+	sort.Slice(waits, func(i, j int) bool {
+		return waits[i].Address < waits[j].Address
+	})
+	return waits
+}
+
+// answer fills the response, it is true when the slave has something to do.
+func (g *GolMasterServer) answer(param *CheckNextTurnParam, response *CheckNextTurnResponse) bool {
+	*response = CheckNextTurnResponse{}
+	ready := g.allAt(g.thisTurn)
+	for slaveId, t := range g.slaveTurnMap {
+		if t != g.thisTurn {
+			response.MissSlaves = append(response.MissSlaves, slaveId)
+		}
+	}
+
+	// the state is taken at a turn no slave has gone past
+	if ready && !g.opened && g.resync && g.stateTurn == NotTake {
+		g.stateTurn = g.thisTurn
+		g.stateRequest++
+	}
+	response.WantState = g.stateTurn != NotTake && g.stateTurn == param.Turn
+	response.StateRequest = g.stateRequest
+
+	response.Exit = g.handle.CheckExit()
+	if response.Exit {
+		g.exited[param.Id] = true
+		return true
+	}
+	response.AllReady = ready && g.thisTurn < g.params.Turns && !g.handle.CheckPause()
+	if response.AllReady {
+		g.opened = true
+		response.Neighbours = make(map[SlaveId]string)
+		for _, slaveId := range neighbours(param.Id, g.tiles(), haloDepth(g.params), g.params.ImageWidth, g.params.ImageHeight) {
+			response.Neighbours[slaveId] = g.slaveAddress[slaveId]
+		}
+	}
+	return response.AllReady || response.WantState && response.StateRequest != param.StateRequest
+}
+
+func (g *GolMasterServer) ReportMyState(param *ReportParam, response *ReportResponse) error {
 	// set the salve's state
 	g.slaveTurnLock.Lock()
 	defer g.slaveTurnLock.Unlock()
+	if param.Epoch != g.epoch || g.stateTurn == NotTake || param.Turn != g.stateTurn {
+		return errors.New("invalid turn")
+	}
+	if param.MyState.X != param.Id.RowStart || param.MyState.Width != param.Id.RowEnd-param.Id.RowStart ||
+		param.MyState.Y != param.Id.ColStart || param.MyState.Height != param.Id.ColEnd-param.Id.ColStart {
+		return errors.New("invalid strip")
+	}
+	if _, err := param.MyState.cells(g.params.ImageWidth, g.params.ImageHeight); err != nil {
+		return err
+	}
 	g.reportStateMap[param.Id] = param.MyState
-	if len(g.reportStateMap) == len(g.slaveTurnMap) && len(g.reportStateMap) == g.slaveCount {
+	if len(g.reportStateMap) == len(g.slaveTurnMap) {
 		// report to handler
 		for _, state := range g.reportStateMap {
 			g.handle.OnSlaveFinish(state)
 		}
-		g.handle.OnTurnComplete(g.thisTurn)
-		g.thisTurn += 1
-		g.reportStateMap = make(map[SlaveId][]Point, g.slaveCount)
+		for _, done := range g.stateWaiting {
+			done <- g.stateTurn
+		}
+		g.stateWaiting = nil
+		g.stateTurn = NotTake
+		g.resync = false
+		g.reportStateMap = make(map[SlaveId]Strip, g.slaveCount)
 	}
 	return nil
 }
 
+// GolSlaveServer takes the edges the neighbouring slaves push.
+type GolSlaveServer struct {
+	params Params
+	lock   sync.Mutex
+	pushed *sync.Cond
+	epoch  int
+	edges  map[int]map[SlaveId][]Strip // turn -> tile -> edges
+}
+
+func NewGolSlaveServer(params Params) *GolSlaveServer {
+	s := &GolSlaveServer{
+		params: params,
+		edges:  make(map[int]map[SlaveId][]Strip),
+	}
+	s.pushed = sync.NewCond(&s.lock)
+	return s
+}
+
+func (s *GolSlaveServer) PushEdges(param *EdgesParam, response *EdgesResponse) error {
+	for _, edge := range param.Edges {
+		if _, err := edge.cells(s.params.ImageWidth, s.params.ImageHeight); err != nil {
+			return err
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if param.Epoch < s.epoch {
+		return nil
+	}
+	if param.Epoch > s.epoch {
+		// pushed before this slave fetched the new strips
+		s.epoch = param.Epoch
+		s.edges = make(map[int]map[SlaveId][]Strip)
+	}
+	if s.edges[param.Turn] == nil {
+		s.edges[param.Turn] = make(map[SlaveId][]Strip)
+	}
+	s.edges[param.Turn][param.From] = param.Edges
+	s.pushed.Broadcast()
+	return nil
+}
+
+// setEpoch drops the edges of earlier epochs.
+func (s *GolSlaveServer) setEpoch(epoch int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if epoch > s.epoch {
+		s.epoch = epoch
+		s.edges = make(map[int]map[SlaveId][]Strip)
+	}
+}
+
+// waitEdges waits up to timeout for the edges of turn from the tiles, false if they didn't all come.
+func (s *GolSlaveServer) waitEdges(turn int, from []SlaveId, timeout time.Duration) ([]Strip, bool) {
+	timer := time.AfterFunc(timeout, func() {
+		s.lock.Lock()
+		s.pushed.Broadcast()
+		s.lock.Unlock()
+	})
+	defer timer.Stop()
+	deadline := time.Now().Add(timeout)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for {
+		var edges []Strip
+		all := true
+		for _, slaveId := range from {
+			strips, ok := s.edges[turn][slaveId]
+			all = all && ok
+			edges = append(edges, strips...)
+		}
+		if all {
+			delete(s.edges, turn)
+			return edges, true
+		}
+		if !time.Now().Before(deadline) {
+			return nil, false
+		}
+		s.pushed.Wait()
+	}
+}
+
 type GolSlaveClient struct {
 	client *rpc.Client
 }
@@ -194,8 +944,7 @@ func NewGolSlaveClient(ip string, port int) *GolSlaveClient {
 	}
 }
 
-func (gc *GolSlaveClient) FetchMyConfig() *SlaveConfigResponse {
-	var param = &SlaveConfigParam{}
+func (gc *GolSlaveClient) FetchMyConfig(param *SlaveConfigParam) *SlaveConfigResponse {
 	var response = &SlaveConfigResponse{}
 	err := gc.client.Call("GolMasterServer.FetchMyConfig", param, response)
 	if err != nil {
@@ -213,25 +962,66 @@ func (gc *GolSlaveClient) CheckNextTurn(param *CheckNextTurnParam) *CheckNextTur
 	return response
 }
 
-func (gc *GolSlaveClient) FetchNextTurn(param *NextTurnParam) *NextTurnResponse {
-	var response = &NextTurnResponse{}
-	err := gc.client.Call("GolMasterServer.FetchNextTurn", param, response)
+func (gc *GolSlaveClient) FetchParams() Params {
+	var response = &ParamsResponse{}
+	err := gc.client.Call("GolMasterServer.FetchParams", &ParamsParam{}, response)
 	if err != nil {
 		log.Fatal("client fetch error:", err)
 	}
-	return response
+	return response.Params
 }
 
-func (gc *GolSlaveClient) ReportMyState(param *ReportParam) {
+// ReportMyState fails if the strips changed since the state was asked for.
+func (gc *GolSlaveClient) ReportMyState(param *ReportParam) error {
 	var response = &ReportResponse{}
-	err := gc.client.Call("GolMasterServer.ReportMyState", param, response)
+	return gc.client.Call("GolMasterServer.ReportMyState", param, response)
+}
+
+// Leave asks the master to let the slave at address go, it is told to exit when its strip is taken over.
+func (gc *GolSlaveClient) Leave(address string) {
+	err := gc.client.Call("GolMasterServer.Leave", &LeaveParam{Address: address}, &LeaveResponse{})
 	if err != nil {
-		log.Fatal("client fetch error:", err)
+		log.Fatal("client leave error:", err)
+	}
+}
+
+// Heartbeat tells the master the slave at address is alive, every HeartbeatInterval until stop is closed.
+func (gc *GolSlaveClient) Heartbeat(address string, stop <-chan bool) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(HeartbeatInterval):
+			_ = gc.client.Call("GolMasterServer.Heartbeat", &HeartbeatParam{Address: address}, &HeartbeatResponse{})
+		}
 	}
-	return
+}
+
+// GolPeerClient pushes edges to another slave.
+type GolPeerClient struct {
+	client *rpc.Client
+}
+
+func NewGolPeerClient(address string) (*GolPeerClient, error) {
+	// a slave on a machine that went away doesn't refuse, it doesn't answer
+	conn, err := net.DialTimeout("tcp", address, HeartbeatInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &GolPeerClient{
+		client: rpc.NewClient(conn),
+	}, nil
+}
+
+func (pc *GolPeerClient) PushEdges(param *EdgesParam) error {
+	var response = &EdgesResponse{}
+	return pc.client.Call("GolSlaveServer.PushEdges", param, response)
 }
 
 type MSCtrl struct {
 	Server *GolMasterServer
 	Client *GolSlaveClient
+
+	Slave   *GolSlaveServer // the edges pushed to this slave
+	Address string          // where the other slaves reach Slave
 }
@@ -0,0 +1,244 @@
+package gol
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/rpc"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestWaitTurnDeadline checks waitTurn gives up at its timeout with nothing else
+// broadcasting, there is no watch running here.
+func TestWaitTurnDeadline(t *testing.T) {
+	g := &GolMasterServer{thisTurn: 5}
+	g.changed = sync.NewCond(&g.slaveTurnLock)
+	done := make(chan int)
+	go func() {
+		done <- g.waitTurn(5, 50*time.Millisecond)
+	}()
+	select {
+	case turn := <-done:
+		if turn != 5 {
+			t.Fatalf("turn %v, want 5", turn)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waitTurn didn't return at its timeout")
+	}
+}
+
+// TestRequestStateDeadline checks requestState fails at its timeout when the strips never come,
+// and returns straight away when there is nothing to resync.
+func TestRequestStateDeadline(t *testing.T) {
+	g := &GolMasterServer{}
+	if err := g.requestState(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	g.resync = true
+	done := make(chan error)
+	go func() {
+		done <- g.requestState(50 * time.Millisecond)
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("no error without the strips")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("requestState didn't return at its timeout")
+	}
+}
+
+// startMaster serves a master on loopback and runs it from images/, it returns the port
+// the slaves reach it on.
+func startMaster(t *testing.T, p Params, events chan Event, keyPresses chan rune) (*GolMasterServer, int) {
+	t.Helper()
+	p.IsMaster = true
+	g := NewGolMasterServer(p, p.SlaveCount, nil)
+	server := rpc.NewServer()
+	if err := server.Register(g); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(l, server)
+	Run(p, events, keyPresses, &MSCtrl{Server: g})
+	return g, l.Addr().(*net.TCPAddr).Port
+}
+
+// startSlave runs a slave of the master at port, done is closed when it has exited.
+func startSlave(t *testing.T, p Params, port int) (address string, done chan bool) {
+	t.Helper()
+	slave := NewGolSlaveServer(p)
+	server := rpc.NewServer()
+	if err := server.Register(slave); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Accept(l)
+
+	events := make(chan Event, 1000)
+	done = make(chan bool)
+	go func() {
+		for range events {
+		}
+		_ = l.Close()
+		close(done)
+	}()
+	hc := &MSCtrl{Client: NewGolSlaveClient("127.0.0.1", port), Slave: slave, Address: l.Addr().String()}
+	Run(Params{ImageWidth: p.ImageWidth, ImageHeight: p.ImageHeight, Turns: p.Turns, Threads: 2}, events, nil, hc)
+	return hc.Address, done
+}
+
+// finalCells waits for the master to finish and checks its world against check/images,
+// then for the slaves to exit.
+func finalCells(t *testing.T, p Params, events chan Event, slaves ...chan bool) {
+	t.Helper()
+	var alive []util.Cell
+	timeout := time.After(60 * time.Second)
+	for finished := false; !finished; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				finished = true
+			} else if e, ok := event.(FinalTurnComplete); ok {
+				alive = e.Alive
+			}
+		case <-timeout:
+			t.Fatal("the master didn't finish")
+		}
+	}
+	for _, done := range slaves {
+		select {
+		case <-done:
+		case <-timeout:
+			t.Fatal("a slave didn't exit")
+		}
+	}
+
+	want := util.ReadAliveCells(fmt.Sprintf("check/images/%vx%vx%v.pgm", p.ImageWidth, p.ImageHeight, p.Turns), p.ImageWidth, p.ImageHeight)
+	got := make(map[util.Cell]bool)
+	for _, cell := range alive {
+		got[cell] = true
+	}
+	if len(got) != len(want) || len(alive) != len(want) {
+		t.Fatalf("%v alive cells, want %v", len(alive), len(want))
+	}
+	for _, cell := range want {
+		if !got[cell] {
+			t.Fatalf("cell (%v,%v) isn't alive", cell.X, cell.Y)
+		}
+	}
+}
+
+// TestMasterSlaves runs the master and its slaves in this process, with strips, tiles and deeper halos.
+func TestMasterSlaves(t *testing.T) {
+	util.Check(os.Chdir(".."))
+	defer os.Chdir("gol")
+	_ = os.Mkdir("out", os.ModePerm)
+
+	tests := []Params{
+		{ImageWidth: 16, ImageHeight: 16, SlaveCount: 2},
+		{ImageWidth: 16, ImageHeight: 16, SlaveCount: 3, TileRows: 2, HaloDepth: 2},
+		{ImageWidth: 64, ImageHeight: 64, SlaveCount: 3, TileRows: 1, HaloDepth: 3, CompressStrips: true},
+		{ImageWidth: 64, ImageHeight: 64, SlaveCount: 3, TileRows: 2, HaloDepth: 4, ChecksumTurns: 10, RebalanceTurns: 10},
+	}
+	for _, p := range tests {
+		p.Turns = 100
+		name := fmt.Sprintf("%vx%v-%v-slaves-%v-rows-%v-deep", p.ImageWidth, p.ImageHeight, p.SlaveCount, p.TileRows, p.HaloDepth)
+		t.Run(name, func(t *testing.T) {
+			events := make(chan Event, 1000)
+			_, port := startMaster(t, p, events, make(chan rune, 10))
+			var slaves []chan bool
+			for i := 0; i < p.SlaveCount; i++ {
+				_, done := startSlave(t, p, port)
+				slaves = append(slaves, done)
+			}
+			finalCells(t, p, events, slaves...)
+		})
+	}
+}
+
+// TestMasterSlavesLoseAndJoin starts with a slave that takes a strip and is gone, then one
+// joins while the run is paused. The world is split again both times.
+func TestMasterSlavesLoseAndJoin(t *testing.T) {
+	util.Check(os.Chdir(".."))
+	defer os.Chdir("gol")
+	_ = os.Mkdir("out", os.ModePerm)
+
+	p := Params{ImageWidth: 64, ImageHeight: 64, Turns: 100, SlaveCount: 2, TileRows: 2, HaloDepth: 2}
+	events := make(chan Event, 1000)
+	keyPresses := make(chan rune, 10)
+	g, port := startMaster(t, p, events, keyPresses)
+	// the master only takes slaves once its world is loaded, so its events are read from the start
+	running := make(chan bool)
+	forwarded := make(chan Event, 1000)
+	go func() {
+		for event := range events {
+			switch e := event.(type) {
+			case CellFlipped:
+			case TurnComplete:
+				if e.CompletedTurns == 10 {
+					close(running)
+				}
+			default:
+				forwarded <- event
+			}
+		}
+		close(forwarded)
+	}()
+
+	// nothing listens where the lost slave said it could be reached
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone := l.Addr().String()
+	_ = l.Close()
+	lost := NewGolSlaveClient("127.0.0.1", port)
+	for lost.FetchMyConfig(&SlaveConfigParam{Address: gone, Capacity: 1}).Wait {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, first := startSlave(t, p, port)
+	// pause once the run is going, to have the next slave join at a known turn
+	select {
+	case <-running:
+	case <-time.After(30 * time.Second):
+		t.Fatal("the run didn't get to turn 10")
+	}
+	keyPresses <- 'p'
+	joined, second := startSlave(t, p, port)
+	for {
+		g.slaveTurnLock.Lock()
+		_, taken := g.strip(joined)
+		waiting := g.spares[joined] || taken
+		_, kept := g.strip(gone)
+		g.slaveTurnLock.Unlock()
+		if kept {
+			t.Fatal("the lost slave still has a strip")
+		}
+		if waiting {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	keyPresses <- 'p'
+
+	finalCells(t, p, forwarded, first, second)
+	g.slaveTurnLock.Lock()
+	defer g.slaveTurnLock.Unlock()
+	if _, ok := g.strip(joined); !ok {
+		t.Error("the slave that joined never took a strip")
+	}
+}
@@ -0,0 +1,210 @@
+package gol
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+)
+
+// Ways of turning a greyscale seed image into cells, bright pixels become alive.
+const (
+	SeedThreshold = "threshold" // alive when >= Params.SeedThreshold
+	SeedOtsu      = "otsu"      // threshold picked by Otsu's method
+	SeedDither    = "dither"    // Floyd-Steinberg error diffusion
+)
+
+// greyImage is a greyscale image with values from 0 to 255.
+type greyImage struct {
+	width, height int
+	pix           []float64 // row by row
+}
+
+// decodeGrey reads a pgm (P5 of any depth or P2) or any png, jpeg or gif as greyscale.
+func decodeGrey(r *bufio.Reader) (*greyImage, error) {
+	if magic, _ := r.Peek(2); string(magic) == "P5" || string(magic) == "P2" {
+		return decodeGreyPgm(r)
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	g := &greyImage{width: bounds.Dx(), height: bounds.Dy()}
+	g.pix = make([]float64, g.width*g.height)
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			c := color.Gray16Model.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray16)
+			g.pix[y*g.width+x] = float64(c.Y) * 255 / 0xffff
+		}
+	}
+	return g, nil
+}
+
+func decodeGreyPgm(r *bufio.Reader) (*greyImage, error) {
+	magic, width, height, maxval, err := readPgmHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if maxval <= 0 || maxval > 0xffff {
+		return nil, fmt.Errorf("Incorrect maxval %v", maxval)
+	}
+	g := &greyImage{width: width, height: height, pix: make([]float64, width*height)}
+	for i := range g.pix {
+		var v int
+		switch {
+		case magic == "P2":
+			_, err = fmt.Fscan(r, &v)
+		case maxval < 256:
+			var b byte
+			b, err = r.ReadByte()
+			v = int(b)
+		default:
+			var b [2]byte
+			_, err = io.ReadFull(r, b[:])
+			v = int(b[0])<<8 | int(b[1])
+		}
+		if err != nil {
+			return nil, err
+		}
+		g.pix[i] = float64(v) * 255 / float64(maxval)
+	}
+	return g, nil
+}
+
+// resample scales the image to width x height, each new pixel is the area weighted
+// average of the pixels it covers.
+func (g *greyImage) resample(width, height int) *greyImage {
+	if g.width == width && g.height == height {
+		return g
+	}
+	out := &greyImage{width: width, height: height, pix: make([]float64, width*height)}
+	sx := float64(g.width) / float64(width)
+	sy := float64(g.height) / float64(height)
+	for y := 0; y < height; y++ {
+		y0, y1 := float64(y)*sy, float64(y+1)*sy
+		for x := 0; x < width; x++ {
+			x0, x1 := float64(x)*sx, float64(x+1)*sx
+			var sum, area float64
+			for py := int(y0); py < g.height && float64(py) < y1; py++ {
+				h := math.Min(y1, float64(py+1)) - math.Max(y0, float64(py))
+				for px := int(x0); px < g.width && float64(px) < x1; px++ {
+					w := math.Min(x1, float64(px+1)) - math.Max(x0, float64(px))
+					sum += g.pix[py*g.width+px] * w * h
+					area += w * h
+				}
+			}
+			out.pix[y*width+x] = sum / area
+		}
+	}
+	return out
+}
+
+// otsuThreshold picks the threshold that maximises the variance between the two classes.
+func (g *greyImage) otsuThreshold() int {
+	var histogram [256]int
+	for _, v := range g.pix {
+		histogram[clampGrey(v)]++
+	}
+	total := len(g.pix)
+	var sumAll float64
+	for i, n := range histogram {
+		sumAll += float64(i * n)
+	}
+
+	var sumBelow float64
+	var below int
+	best, bestVariance := 0, -1.0
+	for t := 0; t < 256; t++ {
+		// pixels < t are dead
+		if below > 0 && below < total {
+			meanBelow := sumBelow / float64(below)
+			meanAbove := (sumAll - sumBelow) / float64(total-below)
+			variance := float64(below) * float64(total-below) * (meanBelow - meanAbove) * (meanBelow - meanAbove)
+			if variance > bestVariance {
+				best, bestVariance = t, variance
+			}
+		}
+		below += histogram[t]
+		sumBelow += float64(t * histogram[t])
+	}
+	if bestVariance < 0 {
+		// a flat image, everything is on one side
+		return 128
+	}
+	return best
+}
+
+// threshold turns each pixel >= t alive (255), the rest dead (0).
+func (g *greyImage) threshold(t int) []byte {
+	cells := make([]byte, len(g.pix))
+	for i, v := range g.pix {
+		if clampGrey(v) >= t {
+			cells[i] = 255
+		}
+	}
+	return cells
+}
+
+// dither spreads the rounding error of each pixel to its neighbours (Floyd-Steinberg),
+// so the density of alive cells follows the brightness.
+func (g *greyImage) dither() []byte {
+	pix := make([]float64, len(g.pix))
+	copy(pix, g.pix)
+	cells := make([]byte, len(pix))
+	spread := func(x, y int, e float64) {
+		if x >= 0 && x < g.width && y < g.height {
+			pix[y*g.width+x] += e
+		}
+	}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			old := pix[y*g.width+x]
+			var v float64
+			if old >= 128 {
+				v = 255
+				cells[y*g.width+x] = 255
+			}
+			e := old - v
+			spread(x+1, y, e*7/16)
+			spread(x-1, y+1, e*3/16)
+			spread(x, y+1, e*5/16)
+			spread(x+1, y+1, e*1/16)
+		}
+	}
+	return cells
+}
+
+// seedCells converts the image to cells of width x height using the mode.
+// A negative threshold is 128, 0 makes every cell alive.
+func (g *greyImage) seedCells(width, height int, mode string, threshold int) ([]byte, error) {
+	g = g.resample(width, height)
+	switch mode {
+	case SeedThreshold, "":
+		if threshold < 0 {
+			threshold = 128
+		}
+		return g.threshold(threshold), nil
+	case SeedOtsu:
+		return g.threshold(g.otsuThreshold()), nil
+	case SeedDither:
+		return g.dither(), nil
+	}
+	return nil, errors.New("unknown seed mode " + mode)
+}
+
+func clampGrey(v float64) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return int(v + 0.5)
+}
@@ -0,0 +1,133 @@
+package gol
+
+import (
+	"bufio"
+	"math"
+	"strings"
+	"testing"
+)
+
+func greyOf(width, height int, pix ...float64) *greyImage {
+	return &greyImage{width: width, height: height, pix: pix}
+}
+
+func alive(cells []byte) int {
+	n := 0
+	for _, c := range cells {
+		if c == 255 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            *greyImage
+		width, height int
+		want          []float64
+	}{
+		{"same size", greyOf(2, 1, 10, 20), 2, 1, []float64{10, 20}},
+		{"halve", greyOf(2, 2, 0, 100, 200, 100), 1, 1, []float64{100}},
+		{"double", greyOf(1, 1, 42), 2, 2, []float64{42, 42, 42, 42}},
+		// each new pixel covers one and a half old ones, or one and two thirds
+		{"three to two", greyOf(3, 1, 0, 255, 0), 2, 1, []float64{85, 85}},
+		{"five to three", greyOf(5, 1, 0, 30, 60, 90, 120), 3, 1, []float64{12, 60, 108}},
+	}
+	for _, test := range tests {
+		got := test.in.resample(test.width, test.height)
+		if got.width != test.width || got.height != test.height || len(got.pix) != len(test.want) {
+			t.Fatalf("%v: got %vx%v with %v pixels", test.name, got.width, got.height, len(got.pix))
+		}
+		for i, v := range test.want {
+			if math.Abs(got.pix[i]-v) > 1e-9 {
+				t.Errorf("%v: pixel %v is %v, want %v", test.name, i, got.pix[i], v)
+			}
+		}
+	}
+}
+
+func TestOtsu(t *testing.T) {
+	// dark and bright pixels with some noise, the threshold goes between them
+	var pix []float64
+	for i := 0; i < 100; i++ {
+		pix = append(pix, float64(30+i%20), float64(180+i%30))
+	}
+	g := greyOf(len(pix), 1, pix...)
+	threshold := g.otsuThreshold()
+	if threshold <= 49 || threshold > 180 {
+		t.Fatalf("threshold %v isn't between 49 and 180", threshold)
+	}
+	cells, err := g.seedCells(len(pix), 1, SeedOtsu, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range cells {
+		if (c == 255) != (i%2 == 1) {
+			t.Fatalf("pixel %v of %v is %v", i, pix[i], c)
+		}
+	}
+
+	if flat := greyOf(2, 1, 77, 77).otsuThreshold(); flat != 128 {
+		t.Errorf("flat image threshold %v, want 128", flat)
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	g := greyOf(4, 1, 0, 100, 128, 255)
+	for _, test := range []struct {
+		threshold, alive int
+	}{
+		{-1, 2}, // 128
+		{0, 4},
+		{1, 3},
+		{128, 2},
+		{255, 1},
+		{256, 0},
+	} {
+		cells, err := g.seedCells(4, 1, SeedThreshold, test.threshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := alive(cells); n != test.alive {
+			t.Errorf("threshold %v: %v alive, want %v", test.threshold, n, test.alive)
+		}
+	}
+	if _, err := g.seedCells(4, 1, "nope", -1); err == nil {
+		t.Error("unknown mode without an error")
+	}
+}
+
+func TestDither(t *testing.T) {
+	for _, grey := range []float64{0, 64, 128, 191, 255} {
+		pix := make([]float64, 64*64)
+		for i := range pix {
+			pix[i] = grey
+		}
+		cells, err := greyOf(64, 64, pix...).seedCells(64, 64, SeedDither, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the density of alive cells follows the brightness
+		density := float64(alive(cells)) / float64(len(cells))
+		if math.Abs(density-grey/255) > 0.02 {
+			t.Errorf("grey %v: density %.3f, want %.3f", grey, density, grey/255)
+		}
+	}
+}
+
+func TestDecodeGreyPgm(t *testing.T) {
+	// a 16 bit P5 and a P2 with a comment
+	p5 := "P5\n2 1\n65535\n" + string([]byte{0xff, 0xff, 0x80, 0x00})
+	p2 := "P2\n# comment\n2 1\n10\n10 5\n"
+	for _, data := range []string{p5, p2} {
+		g, err := decodeGrey(bufio.NewReader(strings.NewReader(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.width != 2 || g.height != 1 || math.Abs(g.pix[0]-255) > 1e-9 || math.Abs(g.pix[1]-127.5) > 0.01 {
+			t.Errorf("%q decoded as %vx%v %v", data, g.width, g.height, g.pix)
+		}
+	}
+}
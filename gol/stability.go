@@ -0,0 +1,91 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// stabilityDetector keeps a hash of the world, updated with every flipped cell, and the
+// hashes of the last generations. A hash seen before means the world may be periodic,
+// it's confirmed by keeping the world and comparing it with the world a period later,
+// so a hash collision is never taken for a cycle.
+type stabilityDetector struct {
+	height  int
+	hash    uint64
+	world   []uint64 // the alive cells, a bit each
+	window  int
+	seen    map[uint64]int // hash -> turn, for the last window turns
+	history []uint64       // hashes of the last window turns, oldest first
+	found   bool
+
+	candidate []uint64 // the world a hash repeated at, nil if none
+	period    int      // of the candidate
+	first     int      // turn the candidate's hash was first seen
+	confirmAt int      // turn the world should be the candidate again
+}
+
+func newStabilityDetector(p Params) *stabilityDetector {
+	window := p.StabilityWindow
+	if window <= 0 {
+		window = 64
+	}
+	return &stabilityDetector{
+		height: p.ImageHeight,
+		world:  make([]uint64, (p.ImageWidth*p.ImageHeight+63)/64),
+		window: window,
+		seen:   make(map[uint64]int, window+1),
+	}
+}
+
+// flip adds or removes a cell, the hash of a world is the xor of the hashes of its alive cells.
+func (s *stabilityDetector) flip(cell util.Cell) {
+	i := cell.X*s.height + cell.Y
+	s.hash ^= cellHash(uint64(i))
+	s.world[i/64] ^= 1 << uint(i%64)
+}
+
+// turnComplete records the world after turn completed turns. The first time the world is
+// confirmed to repeat one of the last window generations it returns the period and the
+// first turn of the cycle, a period after the repeat was seen.
+func (s *stabilityDetector) turnComplete(turn int) (period, first int, ok bool) {
+	if s.found {
+		return 0, 0, false
+	}
+	if s.candidate != nil && turn == s.confirmAt {
+		if sameWorld(s.world, s.candidate) {
+			s.found = true
+			return s.period, s.first, true
+		}
+		// the world didn't come round again, the hash collided
+		s.candidate = nil
+	}
+	if prev, seen := s.seen[s.hash]; seen && s.candidate == nil {
+		s.candidate = append([]uint64(nil), s.world...)
+		s.period = turn - prev
+		s.first = prev
+		s.confirmAt = turn + s.period
+	}
+	s.seen[s.hash] = turn
+	s.history = append(s.history, s.hash)
+	if len(s.history) > s.window {
+		if s.seen[s.history[0]] == turn-s.window {
+			delete(s.seen, s.history[0])
+		}
+		s.history = s.history[1:]
+	}
+	return 0, 0, false
+}
+
+func sameWorld(a, b []uint64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cellHash is splitmix64, it spreads the cell index over all 64 bits.
+func cellHash(i uint64) uint64 {
+	i += 0x9e3779b97f4a7c15
+	i = (i ^ (i >> 30)) * 0xbf58476d1ce4e5b9
+	i = (i ^ (i >> 27)) * 0x94d049bb133111eb
+	return i ^ (i >> 31)
+}
@@ -0,0 +1,91 @@
+package gol
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// runStability runs the world on a 16x16 board, feeding the flipped cells to a detector,
+// and returns what it finds in turns turns.
+func runStability(t *testing.T, alive []util.Cell, turns int) (period, first, at int, ok bool) {
+	t.Helper()
+	p := Params{ImageWidth: 16, ImageHeight: 16}
+	s := newStabilityDetector(p)
+	world := make([][]bool, p.ImageWidth)
+	for x := range world {
+		world[x] = make([]bool, p.ImageHeight)
+	}
+	for _, cell := range alive {
+		world[cell.X][cell.Y] = true
+		s.flip(cell)
+	}
+	s.turnComplete(0)
+	for turn := 1; turn <= turns; turn++ {
+		next := make([][]bool, p.ImageWidth)
+		for x := range next {
+			next[x] = make([]bool, p.ImageHeight)
+			for y := range next[x] {
+				n := 0
+				for dx := -1; dx <= 1; dx++ {
+					for dy := -1; dy <= 1; dy++ {
+						if (dx != 0 || dy != 0) && world[(x+dx+16)%16][(y+dy+16)%16] {
+							n++
+						}
+					}
+				}
+				next[x][y] = n == 3 || n == 2 && world[x][y]
+				if next[x][y] != world[x][y] {
+					s.flip(util.Cell{X: x, Y: y})
+				}
+			}
+		}
+		world = next
+		if period, first, ok := s.turnComplete(turn); ok {
+			return period, first, turn, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+func TestStabilityStillLife(t *testing.T) {
+	block := []util.Cell{{X: 3, Y: 3}, {X: 4, Y: 3}, {X: 3, Y: 4}, {X: 4, Y: 4}}
+	period, first, at, ok := runStability(t, block, 10)
+	if !ok || period != 1 || first != 0 {
+		t.Fatalf("block: period %v from turn %v found %v, want period 1 from turn 0", period, first, ok)
+	}
+	if at != 2 {
+		t.Fatalf("block confirmed at turn %v, want 2", at)
+	}
+}
+
+func TestStabilityOscillator(t *testing.T) {
+	blinker := []util.Cell{{X: 5, Y: 6}, {X: 6, Y: 6}, {X: 7, Y: 6}}
+	period, first, _, ok := runStability(t, blinker, 10)
+	if !ok || period != 2 || first != 0 {
+		t.Fatalf("blinker: period %v from turn %v found %v, want period 2 from turn 0", period, first, ok)
+	}
+
+	// a glider comes back to itself on the 16x16 torus after 64 turns
+	glider := []util.Cell{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+	period, first, _, ok = runStability(t, glider, 200)
+	if !ok || period != 64 || first != 0 {
+		t.Fatalf("glider: period %v from turn %v found %v, want period 64 from turn 0", period, first, ok)
+	}
+}
+
+// TestStabilityCollision makes the hash repeat with a different world, it mustn't be taken for a cycle.
+func TestStabilityCollision(t *testing.T) {
+	s := newStabilityDetector(Params{ImageWidth: 16, ImageHeight: 16})
+	s.flip(util.Cell{X: 1, Y: 1})
+	s.turnComplete(0)
+	collided := s.hash
+	for turn := 1; turn < 10; turn++ {
+		s.flip(util.Cell{X: turn, Y: 5})
+		// as if every world after turn 0 hashed the same
+		s.hash = collided
+		if period, first, ok := s.turnComplete(turn); ok {
+			t.Fatalf("turn %v: period %v from turn %v for worlds that never repeat", turn, period, first)
+		}
+	}
+}
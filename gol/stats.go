@@ -0,0 +1,84 @@
+package gol
+
+import (
+	"fmt"
+	"io"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// statsHeader is the first line of a stats csv, the first two columns are the same as check/alive/*.csv.
+const statsHeader = "completed_turns,alive_cells,births,deaths,min_x,min_y,max_x,max_y"
+
+// statsCollector counts the births and deaths of every turn. It keeps the number of
+// alive cells in each column and row, so the bounding box doesn't need the whole panel.
+type statsCollector struct {
+	alive         int
+	births        int
+	deaths        int
+	columns, rows []int // alive cells per x, per y
+}
+
+func newStatsCollector(p Params) *statsCollector {
+	return &statsCollector{
+		columns: make([]int, p.ImageWidth),
+		rows:    make([]int, p.ImageHeight),
+	}
+}
+
+// born adds a cell that became alive, including the cells of the initial world.
+func (s *statsCollector) born(cell util.Cell) {
+	s.alive++
+	s.births++
+	s.columns[cell.X]++
+	s.rows[cell.Y]++
+}
+
+// died removes a cell that stopped being alive.
+func (s *statsCollector) died(cell util.Cell) {
+	s.alive--
+	s.deaths++
+	s.columns[cell.X]--
+	s.rows[cell.Y]--
+}
+
+// reset forgets the births and deaths so far, after the initial world is loaded.
+func (s *statsCollector) reset() {
+	s.births, s.deaths = 0, 0
+}
+
+// turnComplete returns the stats of the turn and starts counting the next one.
+func (s *statsCollector) turnComplete(turn int) TurnStats {
+	stats := TurnStats{
+		CompletedTurns: turn,
+		Alive:          s.alive,
+		Births:         s.births,
+		Deaths:         s.deaths,
+	}
+	stats.MinX, stats.MaxX = span(s.columns)
+	stats.MinY, stats.MaxY = span(s.rows)
+	s.reset()
+	return stats
+}
+
+// span is the first and last non zero index, -1 -1 if there are none.
+func span(counts []int) (int, int) {
+	first, last := -1, -1
+	for i, n := range counts {
+		if n > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	return first, last
+}
+
+// WriteStatsRow writes the line of a turn, the header has to be written first.
+// The bounding box of an empty world is -1,-1,-1,-1.
+func WriteStatsRow(w io.Writer, t TurnStats) error {
+	_, err := fmt.Fprintf(w, "%v,%v,%v,%v,%v,%v,%v,%v\n",
+		t.CompletedTurns, t.Alive, t.Births, t.Deaths, t.MinX, t.MinY, t.MaxX, t.MaxY)
+	return err
+}
@@ -0,0 +1,53 @@
+package gol
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestStatsRows feeds a blinker to the collector and checks the csv rows of each turn.
+func TestStatsRows(t *testing.T) {
+	s := newStatsCollector(Params{ImageWidth: 5, ImageHeight: 5})
+	for _, cell := range []util.Cell{{X: 1, Y: 2}, {X: 2, Y: 2}, {X: 3, Y: 2}} {
+		s.born(cell)
+	}
+	s.reset()
+
+	tests := []struct {
+		born, died []util.Cell
+		row        string
+	}{
+		// turns to vertical
+		{[]util.Cell{{X: 2, Y: 1}, {X: 2, Y: 3}}, []util.Cell{{X: 1, Y: 2}, {X: 3, Y: 2}}, "1,3,2,2,2,1,2,3"},
+		// back to horizontal
+		{[]util.Cell{{X: 1, Y: 2}, {X: 3, Y: 2}}, []util.Cell{{X: 2, Y: 1}, {X: 2, Y: 3}}, "2,3,2,2,1,2,3,2"},
+		// nothing happens
+		{nil, nil, "3,3,0,0,1,2,3,2"},
+		// everything dies, the bounding box is empty
+		{nil, []util.Cell{{X: 1, Y: 2}, {X: 2, Y: 2}, {X: 3, Y: 2}}, "4,0,0,3,-1,-1,-1,-1"},
+	}
+	var buf bytes.Buffer
+	buf.WriteString(statsHeader + "\n")
+	want := []string{statsHeader}
+	for i, test := range tests {
+		for _, cell := range test.born {
+			s.born(cell)
+		}
+		for _, cell := range test.died {
+			s.died(cell)
+		}
+		if err := WriteStatsRow(&buf, s.turnComplete(i+1)); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, test.row)
+	}
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("csv\n%v\nwant\n%v", got, strings.Join(want, "\n"))
+	}
+	if !strings.HasPrefix(statsHeader, "completed_turns,alive_cells,") {
+		t.Errorf("header %q doesn't start like check/alive", statsHeader)
+	}
+}
@@ -0,0 +1,133 @@
+package gol
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+// Strip is the cells X to X+Width and Y to Y+Height of the world (wrapping around),
+// packed one bit a cell column by column from the top. With RLE the bytes are run
+// length encoded.
+type Strip struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+	RLE    bool
+	Bits   []byte
+}
+
+// packStrip packs width x height cells of the panel from x, y, run length encoded if
+// rle is set and it is smaller.
+func packStrip(panel [][]bool, x, y, width, height int, rle bool) Strip {
+	s := Strip{X: x, Y: y, Width: width, Height: height}
+	s.Bits = make([]byte, (width*height+7)/8)
+	bit := 0
+	for i := 0; i < width; i++ {
+		column := panel[(x+i)%len(panel)]
+		for j := 0; j < height; j++ {
+			if column[(y+j)%len(column)] {
+				s.Bits[bit/8] |= 1 << uint(bit%8)
+			}
+			bit++
+		}
+	}
+	if rle {
+		if runs := encodeRuns(s.Bits); len(runs) < len(s.Bits) {
+			s.RLE = true
+			s.Bits = runs
+		}
+	}
+	return s
+}
+
+// cells decodes the bits of the strip, it fails if the strip doesn't fit a world of
+// width x height or has the wrong number of bytes.
+func (s Strip) cells(width, height int) ([]byte, error) {
+	if s.X < 0 || s.X >= width || s.Y < 0 || s.Y >= height ||
+		s.Width < 0 || s.Width > width || s.Height < 0 || s.Height > height {
+		return nil, fmt.Errorf("strip of %vx%v at %v,%v for a world of %vx%v", s.Width, s.Height, s.X, s.Y, width, height)
+	}
+	bits := s.Bits
+	if s.RLE {
+		var err error
+		if bits, err = decodeRuns(s.Bits, (s.Width*s.Height+7)/8); err != nil {
+			return nil, err
+		}
+	}
+	if len(bits) != (s.Width*s.Height+7)/8 {
+		return nil, fmt.Errorf("strip of %vx%v has %v bytes", s.Width, s.Height, len(bits))
+	}
+	return bits, nil
+}
+
+// unpack writes the strip into the panel.
+func (s Strip) unpack(panel [][]bool) error {
+	if len(panel) == 0 {
+		return errors.New("empty world")
+	}
+	bits, err := s.cells(len(panel), len(panel[0]))
+	if err != nil {
+		return err
+	}
+	bit := 0
+	for i := 0; i < s.Width; i++ {
+		column := panel[(s.X+i)%len(panel)]
+		for j := 0; j < s.Height; j++ {
+			column[(s.Y+j)%len(column)] = bits[bit/8]&(1<<uint(bit%8)) != 0
+			bit++
+		}
+	}
+	return nil
+}
+
+// encodeRuns turns the bytes into pairs of a count (1 to 255) and the byte repeated.
+func encodeRuns(bytes []byte) []byte {
+	var runs []byte
+	for i := 0; i < len(bytes); {
+		n := 1
+		for i+n < len(bytes) && n < 255 && bytes[i+n] == bytes[i] {
+			n++
+		}
+		runs = append(runs, byte(n), bytes[i])
+		i += n
+	}
+	return runs
+}
+
+// decodeRuns expands the runs back into size bytes. It fails as soon as they go past size,
+// so broken runs can't make it allocate more, and on runs of 0 which encodeRuns never writes.
+func decodeRuns(runs []byte, size int) ([]byte, error) {
+	if len(runs)%2 != 0 {
+		return nil, errors.New("run length encoding cut short")
+	}
+	bytes := make([]byte, 0, size)
+	for i := 0; i < len(runs); i += 2 {
+		n := int(runs[i])
+		if n == 0 {
+			return nil, errors.New("run length encoding with a run of 0")
+		}
+		if len(bytes)+n > size {
+			return nil, fmt.Errorf("run length encoding longer than %v bytes", size)
+		}
+		for ; n > 0; n-- {
+			bytes = append(bytes, runs[i+1])
+		}
+	}
+	return bytes, nil
+}
+
+// checksum is a hash of the cells of the strip, the same packed either way.
+func (s Strip) checksum() uint64 {
+	bits := s.Bits
+	if s.RLE {
+		var err error
+		if bits, err = decodeRuns(s.Bits, (s.Width*s.Height+7)/8); err != nil {
+			return 0
+		}
+	}
+	h := fnv.New64a()
+	h.Write(bits)
+	return h.Sum64()
+}
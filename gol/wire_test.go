@@ -0,0 +1,212 @@
+package gol
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func randomPanel(width, height int, density float64, r *rand.Rand) [][]bool {
+	panel := make([][]bool, width)
+	for i := range panel {
+		panel[i] = make([]bool, height)
+		for j := range panel[i] {
+			panel[i][j] = r.Float64() < density
+		}
+	}
+	return panel
+}
+
+func emptyPanel(width, height int) [][]bool {
+	return randomPanel(width, height, 0, rand.New(rand.NewSource(0)))
+}
+
+func equalPanels(t *testing.T, got, want [][]bool) {
+	t.Helper()
+	for i := range want {
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Fatalf("cell (%v, %v) is %v, want %v", i, j, got[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+// TestStripRoundTrip packs strips of random worlds and unpacks them into empty ones.
+func TestStripRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range [][2]int{{16, 16}, {64, 64}, {7, 13}, {512, 512}, {1, 1}} {
+		for _, density := range []float64{0, 0.02, 0.5, 1} {
+			for _, rle := range []bool{false, true} {
+				width, height := size[0], size[1]
+				t.Run(fmt.Sprintf("%vx%v-%v-%v", width, height, density, rle), func(t *testing.T) {
+					panel := randomPanel(width, height, density, r)
+					got := emptyPanel(width, height)
+					s := packStrip(panel, 0, 0, width, height, rle)
+					if err := s.unpack(got); err != nil {
+						t.Fatal(err)
+					}
+					equalPanels(t, got, panel)
+				})
+			}
+		}
+	}
+}
+
+// TestStripsRebuildWorld splits a world between slaves the way the master does and
+// rebuilds it from the strips they report.
+func TestStripsRebuildWorld(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	width, height := 100, 37
+	panel := randomPanel(width, height, 0.3, r)
+	for _, weights := range [][]float64{evenly(1), evenly(3), {1, 2, 3, 4}, evenly(7), evenly(width)} {
+		for _, rows := range []int{1, 2, 3} {
+			for _, rle := range []bool{false, true} {
+				got := emptyPanel(width, height)
+				cells := 0
+				for _, id := range tiles(width, height, rows, weights) {
+					cells += id.cells()
+					s := packStrip(panel, id.RowStart, id.ColStart, id.RowEnd-id.RowStart, id.ColEnd-id.ColStart, rle)
+					if err := s.unpack(got); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if cells != width*height {
+					t.Fatalf("%v tiles in %v rows have %v cells", len(weights), rows, cells)
+				}
+				equalPanels(t, got, panel)
+			}
+		}
+	}
+}
+
+// TestHalos checks the edges pushed from the tiles around a tile are every cell within
+// the depth of it that isn't in it, each pushed once.
+func TestHalos(t *testing.T) {
+	width, height := 30, 20
+	for _, count := range []int{1, 2, 5, 9, 12} {
+		for _, rows := range []int{1, 2, 3, 4} {
+			for _, depth := range []int{1, 2, 3, 8} {
+				ids := tiles(width, height, rows, evenly(count))
+				for _, id := range ids {
+					pushed := make([][]int, width)
+					for x := range pushed {
+						pushed[x] = make([]int, height)
+					}
+					for _, other := range neighbours(id, ids, depth, width, height) {
+						for _, r := range halo(id, other, depth, width, height) {
+							for x := r.x0; x < r.x1; x++ {
+								for y := r.y0; y < r.y1; y++ {
+									pushed[x][y]++
+								}
+							}
+						}
+					}
+					mine := tileRect(id)
+					near := make([][]bool, width)
+					for x := range near {
+						near[x] = make([]bool, height)
+					}
+					for x := id.RowStart - depth; x < id.RowEnd+depth; x++ {
+						for y := id.ColStart - depth; y < id.ColEnd+depth; y++ {
+							near[((x%width)+width)%width][((y%height)+height)%height] = true
+						}
+					}
+					for x := range near {
+						for y := range near[x] {
+							want := 0
+							if near[x][y] && !mine.contains(x, y) {
+								want = 1
+							}
+							if pushed[x][y] != want {
+								t.Fatalf("%v tiles in %v rows at depth %v: cell (%v, %v) around %+v pushed %v times",
+									count, rows, depth, x, y, id, pushed[x][y])
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestEdgesWrap checks a corner pushed across the sides of the world.
+func TestEdgesWrap(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	width, height := 20, 9
+	panel := randomPanel(width, height, 0.5, r)
+	got := emptyPanel(width, height)
+	s := packStrip(panel, width-1, height-1, 2, 2, false)
+	if err := s.unpack(got); err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []int{width - 1, 0} {
+		for _, y := range []int{height - 1, 0} {
+			if got[x][y] != panel[x][y] {
+				t.Fatalf("cell (%v, %v) is %v, want %v", x, y, got[x][y], panel[x][y])
+			}
+		}
+	}
+	for x := range got {
+		for y := range got[x] {
+			if got[x][y] && (x != width-1 && x != 0 || y != height-1 && y != 0) {
+				t.Fatalf("cell (%v, %v) outside the strip was written", x, y)
+			}
+		}
+	}
+}
+
+// TestRLESmaller checks a sparse strip is sent run length encoded and a dense one isn't.
+func TestRLESmaller(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	sparse := packStrip(emptyPanel(512, 512), 0, 0, 512, 512, true)
+	if !sparse.RLE || len(sparse.Bits) >= 512*512/8 {
+		t.Fatalf("empty strip packed to %v bytes, rle %v", len(sparse.Bits), sparse.RLE)
+	}
+	dense := packStrip(randomPanel(64, 64, 0.5, r), 0, 0, 64, 64, true)
+	if dense.RLE || len(dense.Bits) != 64*64/8 {
+		t.Fatalf("random strip packed to %v bytes, rle %v", len(dense.Bits), dense.RLE)
+	}
+}
+
+// TestStripErrors checks broken strips are refused.
+func TestStripErrors(t *testing.T) {
+	panel := emptyPanel(8, 8)
+	broken := []Strip{
+		{Width: 8, Height: 8, Bits: make([]byte, 7)},
+		{Width: 8, Height: 8, RLE: true, Bits: []byte{8}},
+		{Width: 8, Height: 16, Bits: make([]byte, 16)},
+		{X: -1, Width: 1, Height: 8, Bits: make([]byte, 1)},
+		{Y: 8, Width: 1, Height: 8, Bits: make([]byte, 1)},
+		{Width: -8, Height: -1, Bits: make([]byte, 1)},
+		// runs past the 8 bytes of the strip, and a run of 0
+		{Width: 8, Height: 8, RLE: true, Bits: []byte{8, 0, 255, 0, 255, 0, 255, 0}},
+		{Width: 8, Height: 8, RLE: true, Bits: []byte{0, 1, 8, 0}},
+	}
+	for _, s := range broken {
+		if err := s.unpack(panel); err == nil {
+			t.Errorf("%+v unpacked without an error", s)
+		}
+	}
+
+	// a slave turns them away instead of unpacking them later
+	slave := NewGolSlaveServer(Params{ImageWidth: 8, ImageHeight: 8})
+	for _, s := range broken {
+		if err := slave.PushEdges(&EdgesParam{Edges: []Strip{s}}, &EdgesResponse{}); err == nil {
+			t.Errorf("%+v pushed without an error", s)
+		}
+	}
+	if len(slave.edges) != 0 {
+		t.Errorf("kept %v broken edges", len(slave.edges))
+	}
+
+	if _, err := decodeRuns([]byte{255, 1, 255, 1}, 300); err == nil {
+		t.Error("decoded 510 bytes for 300")
+	}
+	if _, err := decodeRuns([]byte{1, 7, 0, 7}, 300); err == nil {
+		t.Error("decoded a run of 0")
+	}
+	if bytes, err := decodeRuns(encodeRuns([]byte{1, 1, 1, 2}), 4); err != nil || len(bytes) != 4 {
+		t.Errorf("decoded %v, %v", bytes, err)
+	}
+}
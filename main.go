@@ -3,9 +3,12 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"runtime"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/record"
 	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 // main is the function called when starting Game of Life with 'go run .'
@@ -37,7 +40,117 @@ func main() {
 		10000000000,
 		"Specify the number of turns to process. Defaults to 10000000000.")
 
+	flag.IntVar(
+		&params.AutosaveTurns,
+		"autosave",
+		0,
+		"Specify autosave every n turns. Defaults to 0 (off).")
+
+	flag.DurationVar(
+		&params.AutosaveInterval,
+		"autosaveInterval",
+		0,
+		"Specify autosave every interval, eg. 10m. Defaults to 0 (off).")
+
+	flag.IntVar(
+		&params.AutosaveKeep,
+		"keep",
+		5,
+		"Specify the number of autosaves to keep. Defaults to 5.")
+
+	flag.BoolVar(
+		&params.AutosaveCheckpoint,
+		"autosaveCheckpoint",
+		false,
+		"Specify autosaving checkpoints to resume from instead of pgm images. Defaults to false.")
+
+	flag.StringVar(
+		&params.Resume,
+		"resume",
+		"",
+		"Specify a checkpoint file to continue from. Defaults to none.")
+
+	flag.StringVar(
+		&params.OutputTemplate,
+		"out",
+		"",
+		"Specify the output filename template, {width} {height} {turn} are replaced and a .gz suffix compresses. Defaults to {width}x{height}x{turn}.")
+
+	flag.StringVar(
+		&params.SeedImage,
+		"seed",
+		"",
+		"Specify a greyscale image (pgm, png, jpeg, gif) of any size or an rle pattern to start from. Defaults to images/WxH.pgm.")
+
+	flag.StringVar(
+		&params.SeedMode,
+		"seedMode",
+		gol.SeedThreshold,
+		"Specify how the seed becomes cells: threshold, otsu or dither. Defaults to threshold.")
+
+	flag.IntVar(
+		&params.SeedThreshold,
+		"threshold",
+		128,
+		"Specify the grey level from which a seed pixel is alive, 0 makes every cell alive. Defaults to 128.")
+
+	flag.BoolVar(
+		&params.StopWhenStable,
+		"stopStable",
+		false,
+		"Specify skipping the remaining turns once the world is static or periodic. Defaults to false.")
+
+	flag.IntVar(
+		&params.StabilityWindow,
+		"stableWindow",
+		64,
+		"Specify the longest period looked for. Defaults to 64.")
+
+	flag.BoolVar(
+		&params.Stats,
+		"stats",
+		false,
+		"Specify writing the population, births and deaths of every turn to a csv in out/. Defaults to false.")
+
+	flag.BoolVar(
+		&params.StatsEvents,
+		"statsEvents",
+		false,
+		"Specify sending the stats of every turn as an event. Defaults to false.")
+
+	flag.IntVar(
+		&params.ClusterDistance,
+		"clusterDistance",
+		1,
+		"Specify how far apart cells of a cluster can be when c is pressed, 2 joins cells with a gap. Defaults to 1.")
+
+	flag.StringVar(
+		&params.HeatMap,
+		"heat",
+		"",
+		"Specify a heat map of how often cells flip (flips) or how long they stay alive (alive), written at the end and when h is pressed. Defaults to none.")
+
+	flag.BoolVar(
+		&params.HeatMapColour,
+		"heatColour",
+		false,
+		"Specify writing the heat map as a false colour png. Defaults to false.")
+
+	var journal string
+	flag.StringVar(
+		&journal,
+		"journal",
+		"",
+		"Specify a file to record the events to, for cmd/replay.go. Defaults to none.")
+
 	flag.Parse()
+	util.Check(gol.CheckHeatMap(params.HeatMap))
+
+	if params.Resume != "" {
+		cp, err := gol.LoadCheckpoint(params.Resume)
+		util.Check(err)
+		params = cp.ResumeParams(params)
+	}
 
 	fmt.Println("Threads:", params.Threads)
 	fmt.Println("Width:", params.ImageWidth)
@@ -46,6 +159,20 @@ func main() {
 	keyPresses := make(chan rune, 10)
 	events := make(chan gol.Event, 1000)
 
-	gol.Run(params, events, keyPresses, nil)
+	runEvents := events
+	if journal != "" {
+		file, err := os.Create(journal)
+		util.Check(err)
+		j, err := record.NewJournal(file, params)
+		util.Check(err)
+		runEvents = make(chan gol.Event, 1000)
+		go func() {
+			// the journal is closed before events, so it's all written when sdl returns
+			err := record.Tee(runEvents, events, j)
+			util.Check(err)
+		}()
+	}
+
+	gol.Run(params, runEvents, keyPresses, nil)
 	sdl.Start(params, events, keyPresses)
 }
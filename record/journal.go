@@ -0,0 +1,168 @@
+package record
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// journalMagic starts every journal file.
+const journalMagic = "GOLJRNL1"
+
+// Record types in a journal. Each record is the type, the change in completed turns
+// since the previous record (zigzag varint) and then the fields as uvarints.
+const (
+	journalCellFlipped     byte = iota + 1 // x, y
+	journalTurnComplete                    // no fields
+	journalAliveCellsCount                 // count
+	journalStateChange                     // state
+)
+
+// Journal writes the events needed to replay a run into a compact binary file.
+// Only CellFlipped, TurnComplete, AliveCellsCount and StateChange are kept.
+type Journal struct {
+	file     io.Writer
+	w        *bufio.Writer
+	lastTurn int
+	buf      [3 * binary.MaxVarintLen64]byte
+}
+
+func NewJournal(w io.Writer, p gol.Params) (*Journal, error) {
+	j := &Journal{file: w, w: bufio.NewWriter(w)}
+	if _, err := j.w.WriteString(journalMagic); err != nil {
+		return nil, err
+	}
+	n := binary.PutUvarint(j.buf[:], uint64(p.ImageWidth))
+	n += binary.PutUvarint(j.buf[n:], uint64(p.ImageHeight))
+	if _, err := j.w.Write(j.buf[:n]); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Event appends the event to the journal.
+func (j *Journal) Event(event gol.Event) error {
+	var kind byte
+	var fields []int
+	switch e := event.(type) {
+	case gol.CellFlipped:
+		kind, fields = journalCellFlipped, []int{e.Cell.X, e.Cell.Y}
+	case gol.TurnComplete:
+		kind = journalTurnComplete
+	case gol.AliveCellsCount:
+		kind, fields = journalAliveCellsCount, []int{e.CellsCount}
+	case gol.StateChange:
+		kind, fields = journalStateChange, []int{int(e.NewState)}
+	default:
+		return nil
+	}
+
+	if err := j.w.WriteByte(kind); err != nil {
+		return err
+	}
+	n := binary.PutVarint(j.buf[:], int64(event.GetCompletedTurns()-j.lastTurn))
+	for _, f := range fields {
+		n += binary.PutUvarint(j.buf[n:], uint64(f))
+	}
+	j.lastTurn = event.GetCompletedTurns()
+	_, err := j.w.Write(j.buf[:n])
+	return err
+}
+
+// Close flushes the journal, and closes the file it is written to if it can be closed.
+func (j *Journal) Close() error {
+	if err := j.w.Flush(); err != nil {
+		return err
+	}
+	if c, ok := j.file.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// JournalReader reads back the events of a journal.
+type JournalReader struct {
+	r        *bufio.Reader
+	lastTurn int
+
+	Width, Height int
+}
+
+func NewJournalReader(r io.Reader) (*JournalReader, error) {
+	j := &JournalReader{r: bufio.NewReader(r)}
+	magic := make([]byte, len(journalMagic))
+	if _, err := io.ReadFull(j.r, magic); err != nil || string(magic) != journalMagic {
+		return nil, errors.New("not a journal file")
+	}
+	width, err := binary.ReadUvarint(j.r)
+	if err != nil {
+		return nil, err
+	}
+	height, err := binary.ReadUvarint(j.r)
+	if err != nil {
+		return nil, err
+	}
+	j.Width, j.Height = int(width), int(height)
+	return j, nil
+}
+
+// Params returns the board size of the journal, for sdl.Start.
+func (j *JournalReader) Params() gol.Params {
+	return gol.Params{ImageWidth: j.Width, ImageHeight: j.Height}
+}
+
+// Next returns the next event, or io.EOF at the end of the journal.
+func (j *JournalReader) Next() (gol.Event, error) {
+	kind, err := j.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	delta, err := binary.ReadVarint(j.r)
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	turn := j.lastTurn + int(delta)
+	j.lastTurn = turn
+
+	var count int
+	switch kind {
+	case journalCellFlipped:
+		count = 2
+	case journalAliveCellsCount, journalStateChange:
+		count = 1
+	case journalTurnComplete:
+	default:
+		return nil, errors.New("corrupt journal")
+	}
+	fields := make([]int, count)
+	for i := range fields {
+		f, err := binary.ReadUvarint(j.r)
+		if err != nil {
+			return nil, unexpected(err)
+		}
+		fields[i] = int(f)
+	}
+
+	switch kind {
+	case journalCellFlipped:
+		return gol.CellFlipped{CompletedTurns: turn, Cell: util.Cell{X: fields[0], Y: fields[1]}}, nil
+	case journalAliveCellsCount:
+		return gol.AliveCellsCount{CompletedTurns: turn, CellsCount: fields[0]}, nil
+	case journalStateChange:
+		return gol.StateChange{CompletedTurns: turn, NewState: gol.State(fields[0])}, nil
+	default:
+		return gol.TurnComplete{CompletedTurns: turn}, nil
+	}
+}
+
+// unexpected turns an EOF in the middle of a record into io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package record
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// closeBuffer is a journal file that remembers being closed.
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+// TestJournalRoundTrip writes events through Tee into a journal and reads them back.
+func TestJournalRoundTrip(t *testing.T) {
+	written := []gol.Event{
+		gol.StateChange{CompletedTurns: 0, NewState: gol.Executing},
+		gol.CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: 3, Y: 4}},
+		gol.CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: 511, Y: 0}},
+		gol.TurnComplete{CompletedTurns: 1},
+		gol.CellFlipped{CompletedTurns: 2, Cell: util.Cell{X: 3, Y: 4}},
+		gol.TurnComplete{CompletedTurns: 2},
+		gol.AliveCellsCount{CompletedTurns: 2, CellsCount: 1234567},
+		gol.TurnComplete{CompletedTurns: 300},
+		gol.StateChange{CompletedTurns: 300, NewState: gol.Paused},
+		// a turn going back is written as a negative change
+		gol.StateChange{CompletedTurns: 250, NewState: gol.Executing},
+		gol.StateChange{CompletedTurns: 250, NewState: gol.Quitting},
+	}
+
+	file := &closeBuffer{}
+	j, err := NewJournal(file, gol.Params{ImageWidth: 512, ImageHeight: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan gol.Event)
+	out := make(chan gol.Event, len(written)+1)
+	done := make(chan error)
+	go func() {
+		done <- Tee(events, out, j)
+	}()
+	for _, e := range written {
+		events <- e
+	}
+	// an event the journal doesn't keep
+	events <- gol.FinalTurnComplete{CompletedTurns: 250}
+	close(events)
+	for range out {
+	}
+	// out is closed, so the journal must be flushed and closed already
+	if !file.closed {
+		t.Fatal("journal not closed before out")
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewJournalReader(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Width != 512 || r.Height != 256 {
+		t.Fatalf("size %vx%v, want 512x256", r.Width, r.Height)
+	}
+	for i, want := range written {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatalf("event %v: %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("event %v is %#v, want %#v", i, got, want)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("after the last event got %v, want io.EOF", err)
+	}
+}
+
+// TestJournalTruncated checks a journal cut in the middle of a record is an error.
+func TestJournalTruncated(t *testing.T) {
+	var file bytes.Buffer
+	j, err := NewJournal(&file, gol.Params{ImageWidth: 16, ImageHeight: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Event(gol.CellFlipped{CompletedTurns: 5, Cell: util.Cell{X: 300, Y: 300}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := file.Bytes()
+	r, err := NewJournalReader(bytes.NewReader(data[:len(data)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	if _, err := NewJournalReader(bytes.NewReader([]byte("GOLJRNL0"))); err == nil {
+		t.Fatal("read a journal with the wrong magic")
+	}
+}
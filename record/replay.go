@@ -0,0 +1,190 @@
+package record
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// ReplayOptions controls the playback of a journal.
+type ReplayOptions struct {
+	Speed float64 // turns per second, 0 is as fast as possible
+	From  int     // first turn to show
+	Step  int     // turns skipped by a seek, 0 is 100
+}
+
+// replayer feeds a journal to sdl.Start. It keeps the board of the journal and the board
+// on screen, so seeking only sends the cells that differ.
+type replayer struct {
+	file   io.ReadSeeker
+	events chan<- gol.Event
+	opts   ReplayOptions
+
+	reader *JournalReader
+	width  int
+	board  []bool // the board as read from the journal
+	shown  []bool // the board as sent to the events channel
+	dirty  []int  // cells of board that may differ from shown
+	marked []bool
+
+	target int // turns before target are read without being shown
+	pause  bool
+	last   time.Time
+}
+
+// Replay plays the journal into events until 'q' is pressed, then closes events.
+// Keys: 'p' pauses, '+' and '-' double and halve the speed, '>' and '<' seek forward and back.
+// At the end of the journal it waits, so it's still possible to seek back.
+func Replay(file io.ReadSeeker, events chan<- gol.Event, keyPresses <-chan rune, opts ReplayOptions) error {
+	if opts.Step <= 0 {
+		opts.Step = 100
+	}
+	r := &replayer{file: file, events: events, opts: opts, target: opts.From}
+	defer close(events)
+	if err := r.rewind(); err != nil {
+		return err
+	}
+	size := r.reader.Width * r.reader.Height
+	r.width = r.reader.Width
+	r.board = make([]bool, size)
+	r.shown = make([]bool, size)
+	r.marked = make([]bool, size)
+
+	turn := 0
+	ended := false
+	for {
+		// controls, blocking while paused or at the end
+		for {
+			var key rune
+			if r.pause || ended {
+				key = <-keyPresses
+			} else {
+				select {
+				case key = <-keyPresses:
+				default:
+				}
+			}
+			if key == 0 {
+				break
+			}
+			switch key {
+			case 'q':
+				return nil
+			case 'p':
+				r.pause = !r.pause
+				if r.pause {
+					fmt.Println("Current replaying turn is ", turn)
+				} else {
+					fmt.Println("Continuing")
+				}
+			case '+':
+				r.opts.Speed *= 2
+			case '-':
+				r.opts.Speed /= 2
+			case '>':
+				r.target = r.position(turn) + r.opts.Step
+				ended = false
+			case '<':
+				r.target = r.position(turn) - r.opts.Step
+				if r.target < 0 {
+					r.target = 0
+				}
+				for i, alive := range r.board {
+					if alive {
+						r.flip(i)
+					}
+				}
+				if err := r.rewind(); err != nil {
+					return err
+				}
+				turn = 0
+				ended = false
+			}
+			if !r.pause && !ended {
+				break
+			}
+		}
+
+		event, err := r.reader.Next()
+		if err == io.EOF {
+			// show wherever a seek past the end got to
+			r.show(turn)
+			fmt.Println("End of journal at turn", turn)
+			ended = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			r.flip(e.Cell.Y*r.width + e.Cell.X)
+		case gol.TurnComplete:
+			turn = e.CompletedTurns
+			if turn >= r.target {
+				r.show(turn)
+				r.wait()
+			}
+		default:
+			if e.GetCompletedTurns() >= r.target {
+				events <- e
+			}
+		}
+	}
+}
+
+// position is the turn being shown, or being sought to.
+func (r *replayer) position(turn int) int {
+	if r.target > turn {
+		return r.target
+	}
+	return turn
+}
+
+// rewind starts reading the journal from the beginning.
+func (r *replayer) rewind() error {
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader, err := NewJournalReader(r.file)
+	if err != nil {
+		return err
+	}
+	r.reader = reader
+	return nil
+}
+
+// flip changes a cell of the journal board.
+func (r *replayer) flip(i int) {
+	r.board[i] = !r.board[i]
+	if !r.marked[i] {
+		r.marked[i] = true
+		r.dirty = append(r.dirty, i)
+	}
+}
+
+// show sends the cells that changed since the last frame and renders it.
+func (r *replayer) show(turn int) {
+	for _, i := range r.dirty {
+		r.marked[i] = false
+		if r.board[i] != r.shown[i] {
+			r.shown[i] = r.board[i]
+			r.events <- gol.CellFlipped{CompletedTurns: turn, Cell: util.Cell{X: i % r.width, Y: i / r.width}}
+		}
+	}
+	r.dirty = r.dirty[:0]
+	r.events <- gol.TurnComplete{CompletedTurns: turn}
+}
+
+// wait keeps to the replay speed.
+func (r *replayer) wait() {
+	if r.opts.Speed > 0 {
+		next := r.last.Add(time.Duration(float64(time.Second) / r.opts.Speed))
+		time.Sleep(time.Until(next))
+	}
+	r.last = time.Now()
+}
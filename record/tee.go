@@ -0,0 +1,31 @@
+package record
+
+import "uk.ac.bris.cs/gameoflife/gol"
+
+// Recorder consumes the events of a run.
+type Recorder interface {
+	Event(event gol.Event) error
+	Close() error
+}
+
+// Tee forwards every event to out and to the recorders, then closes the recorders and out.
+// The recorders are closed first, so they are done once out is closed.
+// The first recorder error is returned, the events keep flowing to out regardless.
+func Tee(events <-chan gol.Event, out chan<- gol.Event, recorders ...Recorder) error {
+	var err error
+	for event := range events {
+		for _, r := range recorders {
+			if e := r.Event(event); e != nil && err == nil {
+				err = e
+			}
+		}
+		out <- event
+	}
+	for _, r := range recorders {
+		if e := r.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	close(out)
+	return err
+}
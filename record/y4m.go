@@ -0,0 +1,163 @@
+package record
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// Y4MOptions controls how the board is rendered into the video.
+type Y4MOptions struct {
+	Stride int  // write a frame every Stride turns, 0 is every turn
+	Scale  int  // each cell is Scale x Scale pixels, 0 is 1
+	Fps    int  // frames per second in the header, 0 is 30
+	Age    bool // colour live cells by how many turns they've been alive
+}
+
+// Y4M writes the board as a YUV4MPEG2 stream, one frame per TurnComplete.
+// It only needs the CellFlipped and TurnComplete events, so a run can be recorded without SDL.
+type Y4M struct {
+	w      *bufio.Writer
+	opts   Y4MOptions
+	width  int
+	height int
+
+	alive []bool
+	age   []int32 // turns each live cell has been alive
+
+	wroteHeader bool
+	frame       []byte // Y, U and V planes of one frame
+}
+
+func NewY4M(w io.Writer, p gol.Params, opts Y4MOptions) *Y4M {
+	if opts.Stride <= 0 {
+		opts.Stride = 1
+	}
+	if opts.Scale <= 0 {
+		opts.Scale = 1
+	}
+	if opts.Fps <= 0 {
+		opts.Fps = 30
+	}
+	return &Y4M{
+		w:      bufio.NewWriter(w),
+		opts:   opts,
+		width:  p.ImageWidth,
+		height: p.ImageHeight,
+		alive:  make([]bool, p.ImageWidth*p.ImageHeight),
+		age:    make([]int32, p.ImageWidth*p.ImageHeight),
+		frame:  make([]byte, 3*p.ImageWidth*opts.Scale*p.ImageHeight*opts.Scale),
+	}
+}
+
+// Event updates the board, other events than CellFlipped and TurnComplete are ignored.
+func (v *Y4M) Event(event gol.Event) error {
+	switch e := event.(type) {
+	case gol.CellFlipped:
+		i := e.Cell.Y*v.width + e.Cell.X
+		v.alive[i] = !v.alive[i]
+		v.age[i] = 0
+	case gol.TurnComplete:
+		if v.opts.Age {
+			for i, alive := range v.alive {
+				if alive {
+					v.age[i]++
+				}
+			}
+		}
+		if e.CompletedTurns%v.opts.Stride == 0 {
+			return v.writeFrame()
+		}
+	}
+	return nil
+}
+
+// Close flushes the frames written so far.
+func (v *Y4M) Close() error {
+	return v.w.Flush()
+}
+
+// Record writes every event until the channel is closed.
+func (v *Y4M) Record(events <-chan gol.Event) error {
+	for event := range events {
+		if err := v.Event(event); err != nil {
+			return err
+		}
+	}
+	return v.Close()
+}
+
+func (v *Y4M) writeFrame() error {
+	scale := v.opts.Scale
+	w, h := v.width*scale, v.height*scale
+	if !v.wroteHeader {
+		// full range 4:4:4, square pixels, progressive
+		_, err := fmt.Fprintf(v.w, "YUV4MPEG2 W%v H%v F%v:1 Ip A1:1 C444 XCOLORRANGE=FULL\n", w, h, v.opts.Fps)
+		if err != nil {
+			return err
+		}
+		v.wroteHeader = true
+	}
+
+	plane := w * h
+	for y := 0; y < v.height; y++ {
+		for x := 0; x < v.width; x++ {
+			i := y*v.width + x
+			var cy, cu, cv byte = 0, 128, 128
+			if v.alive[i] {
+				cy, cu, cv = 255, 128, 128
+				if v.opts.Age {
+					cy, cu, cv = ageColour(v.age[i])
+				}
+			}
+			for dy := 0; dy < scale; dy++ {
+				row := (y*scale+dy)*w + x*scale
+				for dx := 0; dx < scale; dx++ {
+					v.frame[row+dx] = cy
+					v.frame[plane+row+dx] = cu
+					v.frame[2*plane+row+dx] = cv
+				}
+			}
+		}
+	}
+
+	if _, err := v.w.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+	_, err := v.w.Write(v.frame)
+	return err
+}
+
+// ageColour goes from white for newborn cells through yellow and red to blue for old ones.
+func ageColour(age int32) (y, u, v byte) {
+	var r, g, b float64
+	switch {
+	case age <= 1:
+		r, g, b = 255, 255, 255
+	case age <= 4:
+		r, g, b = 255, 255, 0
+	case age <= 16:
+		r, g, b = 255, 64, 0
+	case age <= 64:
+		r, g, b = 160, 0, 160
+	default:
+		r, g, b = 32, 64, 255
+	}
+	// BT.601 full range
+	y = clamp(0.299*r + 0.587*g + 0.114*b)
+	u = clamp(-0.168736*r - 0.331264*g + 0.5*b + 128)
+	v = clamp(0.5*r - 0.418688*g - 0.081312*b + 128)
+	return
+}
+
+func clamp(f float64) byte {
+	if f < 0 {
+		return 0
+	}
+	if f > 255 {
+		return 255
+	}
+	return byte(f + 0.5)
+}
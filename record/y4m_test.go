@@ -0,0 +1,83 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestY4MRoundTrip records an odd sized board and reads the stream back frame by frame.
+func TestY4MRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	v := NewY4M(&buf, gol.Params{ImageWidth: 3, ImageHeight: 2}, Y4MOptions{Stride: 2, Scale: 2, Fps: 25})
+	for _, event := range []gol.Event{
+		gol.CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: 0, Y: 0}},
+		gol.CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: 2, Y: 1}},
+		gol.TurnComplete{CompletedTurns: 1},
+		gol.TurnComplete{CompletedTurns: 2},
+		gol.CellFlipped{CompletedTurns: 3, Cell: util.Cell{X: 0, Y: 0}},
+		gol.TurnComplete{CompletedTurns: 3},
+		gol.TurnComplete{CompletedTurns: 4},
+	} {
+		if err := v.Event(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(&buf)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := strings.Fields(header)
+	if tags[0] != "YUV4MPEG2" {
+		t.Fatalf("stream starts with %q", tags[0])
+	}
+	for _, tag := range []string{"W6", "H4", "F25:1", "C444"} {
+		found := false
+		for _, got := range tags[1:] {
+			found = found || got == tag
+		}
+		if !found {
+			t.Errorf("header %q without %v", header, tag)
+		}
+	}
+
+	// a frame every 2 turns, the planes are 6x4 pixels each
+	want := [][]string{
+		{"WW....", "WW....", "....WW", "....WW"},
+		{"......", "......", "....WW", "....WW"},
+	}
+	for n, rows := range want {
+		marker, err := r.ReadString('\n')
+		if err != nil || marker != "FRAME\n" {
+			t.Fatalf("frame %v starts with %q, %v", n, marker, err)
+		}
+		frame := make([]byte, 3*6*4)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			t.Fatalf("frame %v: %v", n, err)
+		}
+		for y, row := range rows {
+			for x, c := range row {
+				luma := byte(0)
+				if c == 'W' {
+					luma = 255
+				}
+				if i := y*6 + x; frame[i] != luma || frame[24+i] != 128 || frame[48+i] != 128 {
+					t.Fatalf("frame %v pixel (%v,%v) is %v %v %v", n, x, y, frame[i], frame[24+i], frame[48+i])
+				}
+			}
+		}
+	}
+	if rest, _ := r.ReadString('\n'); rest != "" {
+		t.Fatalf("%v bytes after the last frame", len(rest))
+	}
+}
@@ -24,6 +24,18 @@ sdlLoop:
 					keyPresses <- 'q'
 				case sdl.K_k:
 					keyPresses <- 'k'
+				case sdl.K_c:
+					keyPresses <- 'c'
+				case sdl.K_h:
+					keyPresses <- 'h'
+				case sdl.K_EQUALS, sdl.K_PLUS, sdl.K_KP_PLUS:
+					keyPresses <- '+'
+				case sdl.K_MINUS, sdl.K_KP_MINUS:
+					keyPresses <- '-'
+				case sdl.K_RIGHT:
+					keyPresses <- '>'
+				case sdl.K_LEFT:
+					keyPresses <- '<'
 				}
 			}
 		}
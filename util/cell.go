@@ -15,7 +15,11 @@ func ReadAliveCells(path string, width, height int) []Cell {
 	//data, ioError := ioutil.ReadFile("check/images/" + fmt.Sprintf("%vx%vx%v.pgm", width, height, turns))
 	data, ioError := ioutil.ReadFile(path)
 	Check(ioError)
+	return PgmAliveCells(data, width, height)
+}
 
+// PgmAliveCells is ReadAliveCells for a pgm already read, it panics if it isn't a width x height pgm.
+func PgmAliveCells(data []byte, width, height int) []Cell {
 	fields := strings.Fields(string(data))
 
 	if fields[0] != "P5" {
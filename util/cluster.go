@@ -0,0 +1,83 @@
+package util
+
+import "math"
+
+// Cluster is a group of connected alive cells on the torus.
+// Cells are unwrapped: a cluster crossing the edge of the board keeps going past it,
+// so MinX and MinY are on the board but MaxX and MaxY may be past the width and height.
+// Take each coordinate modulo the width or height to get back to the board.
+type Cluster struct {
+	Cells                  []Cell
+	Size                   int
+	MinX, MinY, MaxX, MaxY int
+	CentreX, CentreY       float64 // on the board
+}
+
+// Clusters labels the alive cells of a width x height board that wraps around.
+// Cells up to distance apart (in both x and y) are connected: 1 is the 8 neighbours
+// of getNeighbours, 2 also joins cells with one dead cell between them.
+// A cluster that wraps all the way round the board has overlapping unwrapped cells.
+func Clusters(alive []Cell, width, height, distance int) []Cluster {
+	index := make(map[Cell]int, len(alive))
+	for i, cell := range alive {
+		index[cell] = i
+	}
+	visited := make([]bool, len(alive))
+
+	var clusters []Cluster
+	for i, start := range alive {
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		cells := []Cell{start}
+		for next := 0; next < len(cells); next++ {
+			u := cells[next]
+			for dx := -distance; dx <= distance; dx++ {
+				for dy := -distance; dy <= distance; dy++ {
+					cell := Cell{X: ((u.X+dx)%width + width) % width, Y: ((u.Y+dy)%height + height) % height}
+					if j, ok := index[cell]; ok && !visited[j] {
+						visited[j] = true
+						cells = append(cells, Cell{X: u.X + dx, Y: u.Y + dy})
+					}
+				}
+			}
+		}
+		clusters = append(clusters, newCluster(cells, width, height))
+	}
+	return clusters
+}
+
+// newCluster moves the unwrapped cells so the bounding box starts on the board.
+func newCluster(cells []Cell, width, height int) Cluster {
+	c := Cluster{Cells: cells, Size: len(cells), MinX: cells[0].X, MinY: cells[0].Y, MaxX: cells[0].X, MaxY: cells[0].Y}
+	for _, cell := range cells {
+		if cell.X < c.MinX {
+			c.MinX = cell.X
+		}
+		if cell.Y < c.MinY {
+			c.MinY = cell.Y
+		}
+		if cell.X > c.MaxX {
+			c.MaxX = cell.X
+		}
+		if cell.Y > c.MaxY {
+			c.MaxY = cell.Y
+		}
+	}
+	shiftX := (c.MinX%width+width)%width - c.MinX
+	shiftY := (c.MinY%height+height)%height - c.MinY
+	c.MinX, c.MaxX = c.MinX+shiftX, c.MaxX+shiftX
+	c.MinY, c.MaxY = c.MinY+shiftY, c.MaxY+shiftY
+
+	var sumX, sumY float64
+	for i := range cells {
+		cells[i].X += shiftX
+		cells[i].Y += shiftY
+		sumX += float64(cells[i].X)
+		sumY += float64(cells[i].Y)
+	}
+	c.CentreX = math.Mod(sumX/float64(len(cells)), float64(width))
+	c.CentreY = math.Mod(sumY/float64(len(cells)), float64(height))
+	return c
+}
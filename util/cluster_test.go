@@ -0,0 +1,56 @@
+package util
+
+import "testing"
+
+// TestClustersAcrossEdge has a blinker across the left and right edges, a pair of cells
+// across the top and bottom, a pair across the corner and a cell on its own.
+func TestClustersAcrossEdge(t *testing.T) {
+	alive := []Cell{
+		{X: 9, Y: 3}, {X: 0, Y: 3}, {X: 1, Y: 3},
+		{X: 4, Y: 7}, {X: 4, Y: 0},
+		{X: 9, Y: 7}, {X: 0, Y: 0},
+		{X: 6, Y: 4},
+	}
+	clusters := Clusters(alive, 10, 8, 1)
+	if len(clusters) != 4 {
+		t.Fatalf("%v clusters, want 4: %+v", len(clusters), clusters)
+	}
+	bySize := make(map[int][]Cluster)
+	for _, c := range clusters {
+		bySize[c.Size] = append(bySize[c.Size], c)
+	}
+
+	blinker := bySize[3][0]
+	if blinker.MinX != 9 || blinker.MaxX != 11 || blinker.MinY != 3 || blinker.MaxY != 3 {
+		t.Errorf("blinker box is %v,%v to %v,%v, want 9,3 to 11,3", blinker.MinX, blinker.MinY, blinker.MaxX, blinker.MaxY)
+	}
+	if blinker.CentreX != 0 || blinker.CentreY != 3 {
+		t.Errorf("blinker centre is %v,%v, want 0,3", blinker.CentreX, blinker.CentreY)
+	}
+	for _, cell := range blinker.Cells {
+		if cell.X < 9 || cell.X > 11 {
+			t.Errorf("blinker cell %v isn't unwrapped", cell)
+		}
+	}
+
+	if len(bySize[2]) != 2 {
+		t.Fatalf("%v pairs, want 2: %+v", len(bySize[2]), clusters)
+	}
+	for _, pair := range bySize[2] {
+		if pair.MaxY-pair.MinY != 1 || pair.MinY != 7 {
+			t.Errorf("pair %+v doesn't go from y 7 to 8", pair)
+		}
+		if pair.MinX == 9 && pair.MaxX != 10 || pair.MinX != 9 && pair.MaxX != pair.MinX {
+			t.Errorf("pair %+v has the wrong x", pair)
+		}
+	}
+
+	if len(bySize[1]) != 1 || bySize[1][0].Cells[0] != (Cell{X: 6, Y: 4}) {
+		t.Errorf("the cell on its own is %+v", bySize[1])
+	}
+
+	// the clusters are at least 3 apart, round the edges too
+	if n := len(Clusters(alive, 10, 8, 2)); n != 4 {
+		t.Errorf("%v clusters 2 apart, want 4", n)
+	}
+}